@@ -28,6 +28,7 @@ import (
 
 	"github.com/andreaskaris/cni-ethtool/pkg/ethtool"
 	"github.com/andreaskaris/cni-ethtool/pkg/helpers"
+	"github.com/andreaskaris/cni-ethtool/pkg/podpolicy"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -81,12 +82,18 @@ const (
 		{
 		  "type": "cni-ethtool",
 		  "debug": true,
+		  "backend": %q,
+		  "kubeconfig": %q,
+		  "configVersion": "v1",
 		  "ethtool": %s
 		}
 		]
 	  }`
 )
 
+// nodeKubeconfig holds the credentials of the kubelet on the kind nodes, they may read the pods of their node.
+const nodeKubeconfig = "/etc/kubernetes/kubelet.conf"
+
 func TestRun(t *testing.T) {
 	tcs := map[string]struct {
 		es ethtool.EthtoolConfigs
@@ -94,8 +101,8 @@ func TestRun(t *testing.T) {
 		"Test EthtoolConfig 1": {
 			map[string]ethtool.EthtoolConfig{
 				"eth0": {
-					"self": {"tx-checksumming": false, "rx-checksumming": false},
-					"peer": {"tx-checksumming": false, "rx-checksumming": false},
+					Self: &ethtool.Settings{Features: map[string]bool{"tx-checksumming": false, "rx-checksumming": false}},
+					Peer: &ethtool.Settings{Features: map[string]bool{"tx-checksumming": false, "rx-checksumming": false}},
 				},
 			},
 		},
@@ -104,7 +111,8 @@ func TestRun(t *testing.T) {
 		t.Run(desc, func(t *testing.T) {
 			deploymentFeature := features.New("cni-ethtool normal handling").
 				Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
-					daemonSet, cm := deployCNITool(ctx, t, cfg, installerDeployScript, generateCNIConfiguration(tc.es))
+					daemonSet, cm := deployCNITool(ctx, t, cfg, installerDeployScript,
+						generateCNIConfiguration(ethtool.BackendNetlink, "", tc.es))
 					enableEthtool(t, ctx, cfg, daemonSet)
 					ctx = context.WithValue(ctx, installerName, daemonSet)
 					return context.WithValue(ctx, installerConfigMapName, cm)
@@ -182,8 +190,8 @@ func TestNoEthtool(t *testing.T) {
 		"Test EthtoolConfig 1": {
 			map[string]ethtool.EthtoolConfig{
 				"eth0": {
-					"self": {"tx-checksumming": false, "rx-checksumming": false},
-					"peer": {"tx-checksumming": false, "rx-checksumming": false},
+					Self: &ethtool.Settings{Features: map[string]bool{"tx-checksumming": false, "rx-checksumming": false}},
+					Peer: &ethtool.Settings{Features: map[string]bool{"tx-checksumming": false, "rx-checksumming": false}},
 				},
 			},
 		},
@@ -192,7 +200,8 @@ func TestNoEthtool(t *testing.T) {
 		t.Run(desc, func(t *testing.T) {
 			deploymentFeature := features.New("cni-ethtool handling of missing binary").
 				Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
-					daemonSet, cm := deployCNITool(ctx, t, cfg, installerDeployScript, generateCNIConfiguration(tc.es))
+					daemonSet, cm := deployCNITool(ctx, t, cfg, installerDeployScript,
+						generateCNIConfiguration(ethtool.BackendExec, "", tc.es))
 					disableEthtool(t, ctx, cfg, daemonSet)
 					ctx = context.WithValue(ctx, installerName, daemonSet)
 					return context.WithValue(ctx, installerConfigMapName, cm)
@@ -255,6 +264,104 @@ func TestNoEthtool(t *testing.T) {
 	}
 }
 
+func TestAnnotation(t *testing.T) {
+	tcs := map[string]struct {
+		es         ethtool.EthtoolConfigs
+		annotation string
+		expected   ethtool.EthtoolConfigs
+	}{
+		"Annotation overrides one feature of self": {
+			es: map[string]ethtool.EthtoolConfig{
+				"eth0": {
+					Self: &ethtool.Settings{Features: map[string]bool{"tx-checksumming": false, "rx-checksumming": false}},
+					Peer: &ethtool.Settings{Features: map[string]bool{"tx-checksumming": false, "rx-checksumming": false}},
+				},
+			},
+			annotation: `{"eth0": {"self": {"features": {"tx-checksumming": true}}}}`,
+			expected: map[string]ethtool.EthtoolConfig{
+				"eth0": {
+					Self: &ethtool.Settings{Features: map[string]bool{"tx-checksumming": true, "rx-checksumming": false}},
+					Peer: &ethtool.Settings{Features: map[string]bool{"tx-checksumming": false, "rx-checksumming": false}},
+				},
+			},
+		},
+	}
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			deploymentFeature := features.New("cni-ethtool handling of the pod annotation").
+				Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+					daemonSet, cm := deployCNITool(ctx, t, cfg, installerDeployScript,
+						generateCNIConfiguration(ethtool.BackendNetlink, nodeKubeconfig, tc.es))
+					enableEthtool(t, ctx, cfg, daemonSet)
+					ctx = context.WithValue(ctx, installerName, daemonSet)
+					return context.WithValue(ctx, installerConfigMapName, cm)
+				}).
+				Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+					deployment := newDeployment(cfg.Namespace(), testDeploymentName, testDeploymentImageName, 1)
+					deployment.Spec.Template.Annotations = map[string]string{podpolicy.ConfigAnnotation: tc.annotation}
+					if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+						t.Fatal(err)
+					}
+					if err := waite2e.For(conditions.New(cfg.Client().Resources()).
+						DeploymentAvailable(deployment.Name, deployment.Namespace), waite2e.WithImmediate()); err != nil {
+						t.Fatal(err)
+					}
+					t.Logf("deployment found: %s/%s", deployment.Namespace, deployment.Name)
+
+					return context.WithValue(ctx, testDeploymentName, deployment)
+				}).
+				Assess("test ethtool status inside test pods",
+					func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+						// Retrieve the Deployment from context.
+						dep := ctx.Value(testDeploymentName).(*appsv1.Deployment)
+						selector := fmt.Sprintf("app=%s", dep.Spec.Selector.MatchLabels["app"])
+						// List all pods that belong to the Deployment.
+						listOption := func(lo *metav1.ListOptions) {
+							lo.LabelSelector = selector
+						}
+						pods := &corev1.PodList{}
+						err := cfg.Client().Resources(dep.Namespace).List(context.TODO(), pods, listOption)
+						if err != nil || pods.Items == nil {
+							t.Fatalf("error while getting pods for DaemonSet %+v, selector: %q, err: %q", dep, selector, err)
+						}
+
+						for _, pod := range pods.Items {
+							// The annotation overrides the settings of the plugin configuration.
+							verifyEthtoolSettingsInsidePod(t, ctx, cfg, pod, dep.Name, tc.expected)
+							ifIndexAndES := getIFIndexesFromPod(t, ctx, cfg, pod, dep.Name, tc.expected)
+							verifyEthtoolSettingsOutsidePod(t, ctx, cfg, pod, dep.Name, ifIndexAndES)
+						}
+						return ctx
+					}).
+				Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+					dep := ctx.Value(testDeploymentName).(*appsv1.Deployment)
+					if err := cfg.Client().Resources().Delete(ctx, dep); err != nil {
+						t.Fatal(err)
+					}
+					if err := waite2e.For(conditions.New(cfg.Client().Resources()).ResourceDeleted(dep), waite2e.WithImmediate()); err != nil {
+						t.Fatal(err)
+					}
+					ds := ctx.Value(installerName).(*appsv1.DaemonSet)
+					if err := cfg.Client().Resources().Delete(ctx, ds); err != nil {
+						t.Fatal(err)
+					}
+					if err := waite2e.For(conditions.New(cfg.Client().Resources()).ResourceDeleted(ds), waite2e.WithImmediate()); err != nil {
+						t.Fatal(err)
+					}
+					cm := ctx.Value(installerConfigMapName).(*corev1.ConfigMap)
+					if err := cfg.Client().Resources().Delete(ctx, cm); err != nil {
+						t.Fatal(err)
+					}
+					if err := waite2e.For(conditions.New(cfg.Client().Resources()).ResourceDeleted(cm), waite2e.WithImmediate()); err != nil {
+						t.Fatal(err)
+					}
+					return ctx
+				}).Feature()
+			testenv.Test(t, deploymentFeature)
+		})
+	}
+}
+
 func deployCNITool(ctx context.Context, t *testing.T, cfg *envconf.Config, deploySH, kindnetConfList string) (*appsv1.DaemonSet, *corev1.ConfigMap) {
 	// Delete preexisting CM and create it.
 	cm := newConfigMap(
@@ -434,8 +541,8 @@ func newPrivilegedPod(namespace, name, node, image string) *corev1.Pod {
 	}
 }
 
-func generateCNIConfiguration(es ethtool.EthtoolConfigs) string {
-	return fmt.Sprintf(installerConfigurationTemplate, es.String())
+func generateCNIConfiguration(backend, kubeconfig string, es ethtool.EthtoolConfigs) string {
+	return fmt.Sprintf(installerConfigurationTemplate, backend, kubeconfig, es.String())
 }
 
 func parseEthtoolOutput(out, field string) (bool, error) {
@@ -465,7 +572,7 @@ func verifyEthtoolSettingsInsidePod(t *testing.T, ctx context.Context, cfg *envc
 			t.Log(stderr.String())
 			t.Fatal(err)
 		}
-		for parameter, state := range e.GetSelf() {
+		for parameter, state := range e.GetSelf().Features {
 			if outState, err := parseEthtoolOutput(stdout.String(), parameter); err != nil || state != outState {
 				t.Fatalf("received invalid state for pod %s/%s, interface %q and parameter %q, "+
 					"expected state: %t, got state: %t, got err: %q",
@@ -576,7 +683,7 @@ func verifyEthtoolSettingsOutsidePod(t *testing.T, ctx context.Context, cfg *env
 			t.Log(stderr.String())
 			t.Fatal(err)
 		}
-		for parameter, state := range es.GetPeer() {
+		for parameter, state := range es.GetPeer().Features {
 			if outState, err := parseEthtoolOutput(stdout.String(), parameter); err != nil || state != outState {
 				t.Fatalf("received invalid state for pod %s/%s, interface %q and parameter %q, "+
 					"expected state: %t, got state: %t, got err: %q",
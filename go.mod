@@ -8,11 +8,13 @@ require (
 	github.com/containernetworking/cni v1.2.0
 	github.com/containernetworking/plugins v1.4.1
 	github.com/vishvananda/netlink v1.2.1-beta.2
+	golang.org/x/sys v0.18.0
 	k8s.io/apimachinery v0.30.1
 	k8s.io/utils v0.0.0-20240502163921-fe8a2dddb1d0
+	sigs.k8s.io/yaml v1.6.0
 )
 
 require (
 	github.com/vishvananda/netns v0.0.4 // indirect
-	golang.org/x/sys v0.18.0 // indirect
+	go.yaml.in/yaml/v2 v2.4.2 // indirect
 )
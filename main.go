@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/andreaskaris/cni-ethtool/pkg/ethtool"
 	"github.com/andreaskaris/cni-ethtool/pkg/helpers"
+	"github.com/andreaskaris/cni-ethtool/pkg/kubeclient"
+	"github.com/andreaskaris/cni-ethtool/pkg/podpolicy"
+	"github.com/andreaskaris/cni-ethtool/pkg/state"
 	"github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/types"
 	types100 "github.com/containernetworking/cni/pkg/types/100"
@@ -29,11 +37,47 @@ type PluginConf struct {
 	// and PrevResult.c
 	types.NetConf
 
-	Debug   bool                   `json:"debug"`
-	LogFile string                 `json:"logfile"`
-	Ethtool ethtool.EthtoolConfigs `json:"ethtool"`
+	Debug   bool   `json:"debug"`
+	LogFile string `json:"logfile"`
+	// ConfigVersion selects the schema of Ethtool. Configurations without a version are translated from the
+	// original format that lists offloading attributes directly, see ethtool.ConfigVersionLegacy.
+	ConfigVersion string                 `json:"configVersion"`
+	Ethtool       ethtool.EthtoolConfigs `json:"ethtool"`
+	// Profiles holds named bundles of settings that entries of Ethtool and of per-pod overrides select with
+	// "profile", see ethtool.Profiles.
+	Profiles ethtool.Profiles `json:"profiles"`
+	// StateDir is where the original interface settings are recorded during ADD, so that DEL can restore them.
+	// Defaults to state.DefaultDir.
+	StateDir string `json:"stateDir"`
+	// Backend selects how ethtool settings are read and written, either ethtool.BackendNetlink (default) or
+	// ethtool.BackendExec.
+	Backend string `json:"backend"`
+	// Mode enables a verification pass that re-reads all settings after cmdAdd applied them. With modeStrict,
+	// differences fail the ADD request, with modeWarn they are logged as warnings and with modeBestEffort they are
+	// only logged at debug level. Verification is disabled if Mode is empty.
+	Mode string `json:"mode"`
+	// PolicyDir holds the policy files that select per-pod settings by the namespace, name and UID of the pod in
+	// CNI_ARGS. Defaults to podpolicy.DefaultDir.
+	PolicyDir string `json:"policyDir"`
+	// Kubeconfig enables per-pod settings from annotation podpolicy.ConfigAnnotation of the pod in CNI_ARGS. The
+	// annotation is read from the Kubernetes API with the credentials of this kubeconfig file. If the API cannot be
+	// reached, ADD and CHECK fail.
+	Kubeconfig string `json:"kubeconfig"`
+	// Policy restricts the settings that per-pod overrides from the pod annotation and from RuntimeConfig may change.
+	Policy ethtool.Policy `json:"policy"`
+	// RuntimeConfig holds the settings that the runtime passes for capability "ethtool", e.g. from pod annotations.
+	// They override the settings of Ethtool per interface, see ethtool.EthtoolConfigs.Merge.
+	RuntimeConfig struct {
+		Ethtool ethtool.EthtoolConfigs `json:"ethtool,omitempty"`
+	} `json:"runtimeConfig"`
 }
 
+const (
+	modeStrict     = "strict"
+	modeBestEffort = "besteffort"
+	modeWarn       = "warn"
+)
+
 type customLogger struct {
 	slog.Logger
 	PluginName string
@@ -64,6 +108,11 @@ func (c *customLogger) Info(msg string, args ...any) {
 	c.Logger.Info(msg, a...)
 }
 
+func (c *customLogger) Warn(msg string, args ...any) {
+	a := append([]any{"cni-plugin", c.PluginName}, args...)
+	c.Logger.Warn(msg, a...)
+}
+
 func parseConfig(stdin []byte) (*PluginConf, error) {
 	conf := PluginConf{}
 
@@ -75,13 +124,54 @@ func parseConfig(stdin []byte) (*PluginConf, error) {
 		return nil, fmt.Errorf("could not parse prevResult: %v", err)
 	}
 
-	if !conf.Ethtool.IsValid() {
-		return nil, fmt.Errorf("provided ethtool configuration %+v is not valid", conf.Ethtool)
+	if errs := conf.Profiles.Validate(conf.ConfigVersion); len(errs) > 0 {
+		return nil, fmt.Errorf("provided ethtool configuration is not valid: %w", errs)
+	}
+	ethtoolConfigs, errs := conf.Ethtool.ResolveProfiles("ethtool", conf.Profiles)
+	if len(errs) == 0 {
+		conf.Ethtool = ethtoolConfigs
+		errs = conf.Ethtool.Validate(conf.ConfigVersion)
+	}
+	runtimeConfig, runtimeConfigErrs := conf.RuntimeConfig.Ethtool.ResolveProfiles("runtimeConfig.ethtool",
+		conf.Profiles)
+	if len(runtimeConfigErrs) == 0 {
+		conf.RuntimeConfig.Ethtool = runtimeConfig
+		runtimeConfigErrs = conf.RuntimeConfig.Ethtool.ValidateOverrides("runtimeConfig.ethtool", conf.ConfigVersion)
+	}
+	errs = append(errs, runtimeConfigErrs...)
+	errs = append(errs, conf.Policy.Validate("policy")...)
+	switch conf.Mode {
+	case "", modeStrict, modeBestEffort, modeWarn:
+	default:
+		errs = append(errs, ethtool.ValidationError{
+			Path:  "mode",
+			Value: fmt.Sprintf("%q", conf.Mode),
+			Rule:  fmt.Sprintf("must be omitted or one of %q", []string{modeStrict, modeBestEffort, modeWarn}),
+		})
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("provided ethtool configuration is not valid: %w", errs)
 	}
 
 	return &conf, nil
 }
 
+// parseDelConfig parses the parts of the network configuration that cmdDel needs. DEL restores what the records in
+// StateDir hold, so it neither reads nor validates the ethtool settings, profiles and policy. A configuration that
+// became invalid after ADD must not keep DEL from cleaning up.
+func parseDelConfig(stdin []byte) (*PluginConf, error) {
+	var raw struct {
+		Debug    bool   `json:"debug"`
+		LogFile  string `json:"logfile"`
+		StateDir string `json:"stateDir"`
+		Backend  string `json:"backend"`
+	}
+	if err := json.Unmarshal(stdin, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse network configuration: %v", err)
+	}
+	return &PluginConf{Debug: raw.Debug, LogFile: raw.LogFile, StateDir: raw.StateDir, Backend: raw.Backend}, nil
+}
+
 // cmdAdd is called for ADD requests
 func cmdAdd(args *skel.CmdArgs) error {
 	conf, err := parseConfig(args.StdinData)
@@ -94,6 +184,11 @@ func cmdAdd(args *skel.CmdArgs) error {
 	}
 	logger.Debug("cmdAdd", "conf", conf, "conf.Logfile", conf.LogFile, "conf.Debug", conf.Debug)
 
+	backend, err := ethtool.NewBackend(conf.Backend)
+	if err != nil {
+		return err
+	}
+
 	// This plugin must be called as a chained plugin.
 	if conf.PrevResult == nil {
 		return fmt.Errorf("must be called as chained plugin")
@@ -106,76 +201,889 @@ func cmdAdd(args *skel.CmdArgs) error {
 	}
 	logger.Debug("cmdAdd", "prevResult", prevResult)
 
-	// Iterate over each interface of the Ethtool config, e.g. "eth0", "eth1", ...
-	for interfaceName, ethtoolConfig := range conf.Ethtool {
-		// Get the namespace name and the netns.
-		namespace, err := helpers.ExtractInterfaceNamespace(prevResult.Interfaces, interfaceName)
+	store := state.New(conf.StateDir)
+
+	ethtoolConfigs, perPod, err := matchInterfaces(logger, "cmdAdd", conf, args.Args, prevResult.Interfaces)
+	if err != nil {
+		return err
+	}
+
+	// Resolve each interface of the Ethtool config, e.g. "eth0", "eth1", ..., and validate the configured features
+	// against the devices before changing anything.
+	targets, err := resolveAndValidate(logger, "cmdAdd", backend, conf.Policy, ethtoolConfigs, perPod,
+		prevResult.Interfaces)
+	if err != nil {
+		return err
+	}
+	defer closeTargets(targets)
+
+	// Record the original state of every parameter before changing it, so that cmdDel can restore it, and the state
+	// before this request, so that all changes can be rolled back if any step fails.
+	tx := &transaction{logger: logger, backend: backend, store: store}
+	records := map[string]*state.Record{}
+	for interfaceName, ethtoolConfig := range ethtoolConfigs {
+		record, err := recordOriginalState(store, args.ContainerID, backend, tx, ethtoolConfig,
+			targets[interfaceName])
 		if err != nil {
 			return err
 		}
-		netns, err := ns.GetNS(namespace)
+		records[interfaceName] = record
+	}
+
+	for interfaceName, ethtoolConfig := range ethtoolConfigs {
+		target := targets[interfaceName]
+		peerSettings := ethtoolConfig.Get(target.peerClassifier)
+
+		// Set ethtool parameters inside the pod. The "self" index.
+		logger.Debug("cmdAdd", "step", "ethtool set parameters inside namespace", "namespace", target.namespace,
+			"interfaceName", interfaceName, "settings", ethtoolConfig.GetSelf())
+		err = target.netns.Do(func(_ ns.NetNS) error {
+			if err := ethtool.Apply(backend, interfaceName, ethtoolConfig.GetSelf()); err != nil {
+				return err
+			}
+			err := applyRSSContexts(tx, target.netns, interfaceName, ethtoolConfig.GetSelf(), records[interfaceName],
+				&records[interfaceName].SelfRSSContexts)
+			if err != nil {
+				return err
+			}
+			return applyFlowRules(tx, target.netns, interfaceName, ethtoolConfig.GetSelf(), records[interfaceName],
+				&records[interfaceName].SelfFlowRules)
+		})
 		if err != nil {
+			return tx.rollback(err)
+		}
+		// Set ethtool parameters for the peer in global namespace.
+		if peerSettings != nil {
+			logger.Debug("cmdAdd", "step", "ethtool set parameters inside global namespace",
+				"peerInterfaceName", target.peerInterfaceName, "classifier", target.peerClassifier,
+				"settings", peerSettings)
+			if err := applySettings(backend, target.peerInterfaceName, target.vfIndex, peerSettings); err != nil {
+				return tx.rollback(err)
+			}
+			if err := applyRSSContexts(tx, nil, target.peerInterfaceName, peerSettings, records[interfaceName],
+				&records[interfaceName].PeerRSSContexts); err != nil {
+				return tx.rollback(err)
+			}
+			if err := applyFlowRules(tx, nil, target.peerInterfaceName, peerSettings, records[interfaceName],
+				&records[interfaceName].PeerFlowRules); err != nil {
+				return tx.rollback(err)
+			}
+		}
+	}
+	if conf.Mode != "" {
+		if err := verify(logger, conf.Mode, backend, ethtoolConfigs, targets, records); err != nil {
+			return tx.rollback(err)
+		}
+	}
+	logger.Debug("cmdAdd", "done", true)
+	// Pass through the result for the next plugin
+	return types.PrintResult(prevResult, conf.CNIVersion)
+}
+
+// recordOriginalState reads the state of every parameter that ethtoolConfig configures for target and its peer.
+// It stores the state in the record of the interface, so that cmdDel can restore it, adds it to tx and returns the
+// record. If cmdAdd runs again for the same container, the record keeps the values that were recorded first.
+func recordOriginalState(store *state.Store, containerID string, backend ethtool.Backend, tx *transaction,
+	ethtoolConfig ethtool.EthtoolConfig, target *resolvedInterface) (*state.Record, error) {
+	record, err := store.Load(containerID, target.interfaceName)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		record = state.NewRecord(containerID, target.interfaceName)
+	}
+	var prior *ethtool.Settings
+	err = target.netns.Do(func(_ ns.NetNS) error {
+		var err error
+		if prior, err = ethtool.Snapshot(backend, target.interfaceName, ethtoolConfig.GetSelf(), nil); err != nil {
 			return err
 		}
+		record.Self, err = ethtool.Snapshot(backend, target.interfaceName, ethtoolConfig.GetSelf(), record.Self)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	tx.add(target.netns, target.interfaceName, -1, prior)
+	if peerSettings := ethtoolConfig.Get(target.peerClassifier); peerSettings != nil {
+		if record.PeerInterfaceName != target.peerInterfaceName ||
+			record.PeerInterfaceIndex != target.peerInterfaceIndex || record.VFIndex != target.vfIndex {
+			record.Peer = nil
+			record.PeerRSSContexts = nil
+			record.PeerFlowRules = nil
+		}
+		record.PeerInterfaceName = target.peerInterfaceName
+		record.PeerInterfaceIndex = target.peerInterfaceIndex
+		record.VFIndex = target.vfIndex
+		prior, err := snapshotSettings(backend, target.peerInterfaceName, target.vfIndex, peerSettings, nil)
+		if err != nil {
+			return nil, err
+		}
+		record.Peer, err = snapshotSettings(backend, target.peerInterfaceName, target.vfIndex, peerSettings,
+			record.Peer)
+		if err != nil {
+			return nil, err
+		}
+		tx.add(nil, target.peerInterfaceName, target.vfIndex, prior)
+	}
+	if err := store.Save(record); err != nil {
+		return nil, err
+	}
+	tx.logger.Debug("cmdAdd", "step", "recorded original state", "record", record)
+	return record, nil
+}
 
-		// Get the interface index of the interface inside the namespace (e.g. "eth0" has index "2").
-		var interfaceIndex int
-		err = netns.Do(func(_ ns.NetNS) error {
-			var err error
-			interfaceIndex, err = helpers.GetInterfaceIndex(interfaceName)
-			if err != nil {
+// applySettings changes the settings of interface interfaceName. If settings configure an SR-IOV virtual function,
+// interfaceName is its physical function and vfIndex is its index.
+func applySettings(backend ethtool.Backend, interfaceName string, vfIndex int, settings *ethtool.Settings) error {
+	if err := ethtool.Apply(backend, interfaceName, settings); err != nil {
+		return err
+	}
+	if settings == nil || settings.VF.IsEmpty() {
+		return nil
+	}
+	return ethtool.ApplyVF(interfaceName, vfIndex, settings.VF)
+}
+
+// restoreSettings changes the settings of interface interfaceName back to settings, a snapshot from before
+// applySettings, in reverse order, see ethtool.Restore.
+func restoreSettings(backend ethtool.Backend, interfaceName string, vfIndex int, settings *ethtool.Settings) error {
+	if settings != nil && !settings.VF.IsEmpty() {
+		if err := ethtool.ApplyVF(interfaceName, vfIndex, settings.VF); err != nil {
+			return err
+		}
+	}
+	return ethtool.Restore(backend, interfaceName, settings)
+}
+
+// applyRSSContexts creates or updates the additional RSS contexts that settings configure on interface interfaceName.
+// It stores their IDs in recorded, a list of IDs in record, and saves record right away, so that cmdDel deletes them
+// even if cmdAdd does not finish. Contexts that did not exist before are added to tx.
+func applyRSSContexts(tx *transaction, netns ns.NetNS, interfaceName string, settings *ethtool.Settings,
+	record *state.Record, recorded *[]uint32) error {
+	var contexts []ethtool.RSSContext
+	if settings != nil && settings.RSS != nil {
+		contexts = settings.RSS.Contexts
+	}
+	if len(contexts) == 0 && len(*recorded) == 0 {
+		return nil
+	}
+	ids, err := ethtool.ApplyRSSContexts(tx.backend, interfaceName, contexts, *recorded)
+	return recordCreated(tx, netns, interfaceName, ids, func(id uint32) uint32 { return id },
+		ethtool.DeleteRSSContexts, record, recorded, err)
+}
+
+// applyFlowRules installs the flow rules that settings configure on interface interfaceName and records them with
+// their locations like applyRSSContexts.
+func applyFlowRules(tx *transaction, netns ns.NetNS, interfaceName string, settings *ethtool.Settings,
+	record *state.Record, recorded *[]ethtool.FlowRule) error {
+	var rules []ethtool.FlowRule
+	if settings != nil {
+		rules = settings.FlowRules
+	}
+	if len(rules) == 0 && len(*recorded) == 0 {
+		return nil
+	}
+	installed, err := ethtool.ApplyFlowRules(tx.backend, interfaceName, rules, *recorded)
+	return recordCreated(tx, netns, interfaceName, installed, func(rule ethtool.FlowRule) uint32 { return *rule.Loc },
+		ethtool.DeleteFlowRules, record, recorded, err)
+}
+
+// snapshotSettings is ethtool.Snapshot for settings that may configure an SR-IOV virtual function, see applySettings.
+func snapshotSettings(backend ethtool.Backend, interfaceName string, vfIndex int, settings *ethtool.Settings,
+	original *ethtool.Settings) (*ethtool.Settings, error) {
+	snapshot, err := ethtool.Snapshot(backend, interfaceName, settings, original)
+	if err != nil || settings.VF.IsEmpty() {
+		return snapshot, err
+	}
+	snapshot.VF, err = ethtool.SnapshotVF(interfaceName, vfIndex, settings.VF, snapshot.VF)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// transaction holds the state of every interface before cmdAdd changes it, so that all changes can be rolled back if
+// any step fails.
+type transaction struct {
+	logger  *customLogger
+	backend ethtool.Backend
+	store   *state.Store
+	steps   []transactionStep
+}
+
+// transactionStep is the prior state of interface interfaceName in namespace netns. A nil netns stands for the global
+// namespace. vfIndex is the SR-IOV virtual function of interfaceName that prior configures, if any. If undo is set,
+// the step stands for RSS contexts or flow rules that cmdAdd created on interfaceName instead: rollback deletes them
+// with undo, which also removes them from record, and saves record.
+type transactionStep struct {
+	netns         ns.NetNS
+	interfaceName string
+	vfIndex       int
+	prior         *ethtool.Settings
+	undo          func(b ethtool.Backend) error
+	record        *state.Record
+}
+
+func (t *transaction) add(netns ns.NetNS, interfaceName string, vfIndex int, prior *ethtool.Settings) {
+	t.steps = append(t.steps, transactionStep{netns: netns, interfaceName: interfaceName, vfIndex: vfIndex,
+		prior: prior})
+}
+
+// recordCreated stores items, the RSS contexts or flow rules of interface interfaceName after cmdAdd applied them, in
+// recorded, a list in record, and saves record right away, so that cmdDel deletes them even if cmdAdd does not
+// finish. Items whose ID, see id, was not recorded before are added to t, rollback deletes them with deleteCreated.
+// err is the error of applying them, which is returned unless saving record fails first.
+func recordCreated[T any](t *transaction, netns ns.NetNS, interfaceName string, items []T, id func(T) uint32,
+	deleteCreated func(b ethtool.Backend, iface string, items []T) error, record *state.Record, recorded *[]T,
+	err error) error {
+	isRecorded := func(list []T) func(T) bool {
+		return func(item T) bool {
+			return slices.ContainsFunc(list, func(other T) bool { return id(other) == id(item) })
+		}
+	}
+	var created []T
+	for _, item := range items {
+		if !isRecorded(*recorded)(item) {
+			created = append(created, item)
+		}
+	}
+	if len(created) > 0 {
+		undo := func(b ethtool.Backend) error {
+			if err := deleteCreated(b, interfaceName, created); err != nil {
 				return err
 			}
+			*recorded = slices.DeleteFunc(*recorded, isRecorded(created))
 			return nil
-		})
+		}
+		t.steps = append(t.steps, transactionStep{netns: netns, interfaceName: interfaceName, undo: undo,
+			record: record})
+	}
+	*recorded = items
+	if saveErr := t.store.Save(record); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return err
+}
+
+// rollback restores the prior state of all interfaces in reverse order and returns cause. Interfaces that were not
+// changed yet are restored as well, which is a no-op. If the rollback fails, the error is added to cause.
+func (t *transaction) rollback(cause error) error {
+	t.logger.Info("cmdAdd", "step", "rolling back changes", "cause", cause.Error())
+	var errs []error
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		step := t.steps[i]
+		restore := func(_ ns.NetNS) error {
+			if step.undo != nil {
+				return step.undo(t.backend)
+			}
+			return restoreSettings(t.backend, step.interfaceName, step.vfIndex, step.prior)
+		}
+		var err error
+		if step.netns != nil {
+			err = step.netns.Do(restore)
+		} else {
+			err = restore(nil)
+		}
+		if err == nil && step.undo != nil {
+			err = t.store.Save(step.record)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("interface %s: %w", step.interfaceName, err))
+		}
+	}
+	if len(errs) > 0 {
+		err := errors.Join(errs...)
+		t.logger.Info("cmdAdd", "step", "rollback failed", "err", err.Error())
+		return fmt.Errorf("%w; rollback failed, err: %q", cause, err)
+	}
+	return cause
+}
+
+// verify re-reads the settings of all interfaces after cmdAdd applied them, because ethtool does not report every
+// setting that did not take effect, e.g. features that are fixed or that were changed through a dependent feature.
+// Differences are handled according to mode.
+func verify(logger *customLogger, mode string, backend ethtool.Backend, ethtoolConfigs ethtool.EthtoolConfigs,
+	targets map[string]*resolvedInterface, records map[string]*state.Record) error {
+	var mismatches []string
+	for interfaceName, ethtoolConfig := range ethtoolConfigs {
+		m, err := findInterfaceMismatches(backend, ethtoolConfig, targets[interfaceName], records[interfaceName])
 		if err != nil {
 			return err
 		}
+		mismatches = append(mismatches, m...)
+	}
+	if len(mismatches) == 0 {
+		logger.Debug("cmdAdd", "step", "verified settings", "mode", mode)
+		return nil
+	}
+	sort.Strings(mismatches)
+	switch mode {
+	case modeStrict:
+		logger.Info("cmdAdd", "mode", mode, "mismatches", mismatches)
+		return types.NewError(types.ErrInternal, "ethtool settings did not take effect",
+			strings.Join(mismatches, "; "))
+	case modeWarn:
+		logger.Warn("cmdAdd", "mode", mode, "mismatches", mismatches)
+	default:
+		logger.Debug("cmdAdd", "mode", mode, "mismatches", mismatches)
+	}
+	return nil
+}
 
-		logger.Debug("cmdAdd", "step", "found interface namespace and index", "interfaceName", interfaceName,
-			"namespace", namespace, "interfaceIndex", interfaceIndex)
+// matchInterfaces selects the configuration of every interface inside the sandbox from conf.Ethtool, whose keys may
+// be interface names or patterns, see ethtool.EthtoolConfigs.Match. The policies that select the pod in cniArgs, the
+// annotation of the pod and conf.RuntimeConfig.Ethtool override it per interface, in this order. Every source may select
+// conf.Profiles. It returns the configurations keyed by interface name, together with the overrides that conf.Policy
+// restricts, i.e. those of the annotation and of conf.RuntimeConfig.Ethtool, see ethtool.Policy.CheckLinkType.
+func matchInterfaces(logger *customLogger, cmd string, conf *PluginConf, cniArgs string,
+	interfaces []*types100.Interface) (ethtool.EthtoolConfigs, ethtool.EthtoolConfigs, error) {
+	var interfaceNames []string
+	for _, intf := range interfaces {
+		if intf.Sandbox != "" {
+			interfaceNames = append(interfaceNames, intf.Name)
+		}
+	}
+	matched, err := conf.Ethtool.Match(interfaceNames)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// Set ethtool parameters inside the pod. The "self" index.
-		// Set ethtool parameters inside the pod, one by one.
-		for parameter, setting := range ethtoolConfig.GetSelf() {
-			logger.Debug("cmdAdd", "step", "ethtool set parameter inside namespace", "namespace", namespace,
-				"interfaceName", interfaceName, "parameter", parameter, "setting", setting)
-			err = netns.Do(func(_ ns.NetNS) error {
-				_, err := ethtool.Set(interfaceName, parameter, setting)
+	pod, err := podpolicy.ParseArgs(cniArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var overrides []ethtool.EthtoolConfigs
+	restricted := ethtool.EthtoolConfigs{}
+	if !pod.IsEmpty() {
+		policyDir := conf.PolicyDir
+		if policyDir == "" {
+			policyDir = podpolicy.DefaultDir
+		}
+		policies, err := podpolicy.Lookup(policyDir, pod)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, policy := range policies {
+			logger.Debug(cmd, "step", "found policy", "pod", pod, "source", policy.Source, "ethtool", policy.Ethtool)
+			resolved, errs := policy.Ethtool.ResolveProfiles("ethtool", conf.Profiles)
+			if len(errs) > 0 {
+				return nil, nil, fmt.Errorf("policy %s: %w", policy.Source, errs)
+			}
+			override, err := resolved.Match(interfaceNames)
+			if err != nil {
+				return nil, nil, fmt.Errorf("policy %s: %w", policy.Source, err)
+			}
+			overrides = append(overrides, override)
+		}
+	}
+	if conf.Kubeconfig != "" && !pod.IsEmpty() {
+		annotation, err := lookupAnnotation(logger, cmd, conf, pod)
+		if err != nil {
+			return nil, nil, err
+		}
+		override, err := annotation.Match(interfaceNames)
+		if err != nil {
+			return nil, nil, fmt.Errorf("annotation %q: %w", podpolicy.ConfigAnnotation, err)
+		}
+		overrides = append(overrides, override)
+		restricted = restricted.Merge(override)
+	}
+	if len(conf.RuntimeConfig.Ethtool) > 0 {
+		if errs := conf.Policy.Check("runtimeConfig.ethtool", conf.RuntimeConfig.Ethtool); len(errs) > 0 {
+			return nil, nil, fmt.Errorf("per-pod ethtool settings are not allowed by the policy: %w", errs)
+		}
+		override, err := conf.RuntimeConfig.Ethtool.Match(interfaceNames)
+		if err != nil {
+			return nil, nil, fmt.Errorf("runtimeConfig: %w", err)
+		}
+		overrides = append(overrides, override)
+		restricted = restricted.Merge(override)
+	}
+	if len(overrides) == 0 {
+		logger.Debug(cmd, "step", "matched interfaces", "interfaceNames", interfaceNames, "ethtoolConfigs", matched)
+		return matched, restricted, nil
+	}
+	for _, override := range overrides {
+		matched = matched.Merge(override)
+	}
+	// Overrides may leave an interface without "self" or combine "peer" and "parent". Each source was already
+	// validated against its own configuration version.
+	if errs := matched.Validate(ethtool.ConfigVersionLegacy); len(errs) > 0 {
+		return nil, nil, fmt.Errorf("provided ethtool configuration is not valid after applying overrides: %w", errs)
+	}
+	logger.Debug(cmd, "step", "matched interfaces", "interfaceNames", interfaceNames, "pod", pod,
+		"ethtoolConfigs", matched)
+	return matched, restricted, nil
+}
+
+// lookupAnnotation reads the settings of annotation podpolicy.ConfigAnnotation of pod from the Kubernetes API. It
+// returns nil if the pod does not have the annotation.
+func lookupAnnotation(logger *customLogger, cmd string, conf *PluginConf,
+	pod podpolicy.Pod) (ethtool.EthtoolConfigs, error) {
+	client, err := kubeclient.NewFromKubeconfig(conf.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kubeclient.DefaultTimeout)
+	defer cancel()
+	metadata, err := client.GetPodMetadata(ctx, pod.Namespace, pod.Name)
+	if err != nil {
+		return nil, err
+	}
+	// A pod with the same name may have replaced the pod of this request in the meantime.
+	if pod.UID != "" && metadata.UID != pod.UID {
+		return nil, fmt.Errorf("pod %s/%s has UID %q, expected %q", pod.Namespace, pod.Name, metadata.UID, pod.UID)
+	}
+	annotation, err := podpolicy.FromAnnotations(metadata.Annotations, conf.ConfigVersion)
+	if err != nil {
+		return nil, fmt.Errorf("pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	path := fmt.Sprintf("metadata.annotations[%s]", podpolicy.ConfigAnnotation)
+	// The policy also applies to the settings of the profiles that the annotation selects.
+	annotation, errs := annotation.ResolveProfiles(path, conf.Profiles)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("pod %s/%s: %w", pod.Namespace, pod.Name, errs)
+	}
+	if errs := conf.Policy.Check(path, annotation); len(errs) > 0 {
+		return nil, fmt.Errorf("pod %s/%s: per-pod ethtool settings are not allowed by the policy: %w",
+			pod.Namespace, pod.Name, errs)
+	}
+	logger.Debug(cmd, "step", "read pod annotation", "pod", pod, "annotation", podpolicy.ConfigAnnotation,
+		"ethtool", annotation)
+	return annotation, nil
+}
+
+// resolveAndValidate resolves every interface of ethtoolConfigs and checks the configured offloading attributes and
+// private flags against the features and private flags that the interfaces and their peers offer. Per-pod overrides
+// perPod may only configure the peer of veth interfaces, see ethtool.Policy.CheckLinkType. It returns the resolved
+// interfaces keyed by name, the caller must close them with closeTargets.
+func resolveAndValidate(logger *customLogger, cmd string, backend ethtool.Backend, policy ethtool.Policy,
+	ethtoolConfigs, perPod ethtool.EthtoolConfigs,
+	interfaces []*types100.Interface) (_ map[string]*resolvedInterface, err error) {
+	targets := map[string]*resolvedInterface{}
+	defer func() {
+		if err != nil {
+			closeTargets(targets)
+		}
+	}()
+	var errs ethtool.ValidationErrors
+	for interfaceName, ethtoolConfig := range ethtoolConfigs {
+		target, err := resolveInterface(logger, cmd, interfaces, interfaceName, ethtoolConfig)
+		if err != nil {
+			return nil, err
+		}
+		targets[interfaceName] = target
+		if err := policy.CheckLinkType(perPod, interfaceName, target.linkType); err != nil {
+			return nil, err
+		}
+		err = target.netns.Do(func(_ ns.NetNS) error {
+			e, err := ethtoolConfigs.ValidateFeatures(backend, interfaceName, ethtool.SelfClassifier, interfaceName)
+			if err != nil {
 				return err
-			})
+			}
+			errs = append(errs, e...)
+			e, err = ethtoolConfigs.ValidatePrivFlags(backend, interfaceName, ethtool.SelfClassifier, interfaceName)
 			if err != nil {
 				return err
 			}
+			errs = append(errs, e...)
+			e, err = ethtoolConfigs.ValidateFlowRules(backend, interfaceName, ethtool.SelfClassifier, interfaceName)
+			errs = append(errs, e...)
+			return err
+		})
+		if err != nil {
+			return nil, err
 		}
-		// Set ethtool parameters for veth peer in global namespace, if one exists. The "peer" index.
-		if peerSettings := ethtoolConfig.GetPeer(); peerSettings != nil {
-			netnsID, err := helpers.FindNetNSID(namespace)
+		if target.peerInterfaceName != "" {
+			e, err := ethtoolConfigs.ValidateFeatures(backend, interfaceName, target.peerClassifier,
+				target.peerInterfaceName)
 			if err != nil {
-				return fmt.Errorf("could not find namespace id for netns %s, err: %q", namespace, err)
+				return nil, err
 			}
-			peerInterfaceName, err := helpers.ExtractVeth(prevResult.Interfaces, netnsID, interfaceIndex)
+			errs = append(errs, e...)
+			e, err = ethtoolConfigs.ValidatePrivFlags(backend, interfaceName, target.peerClassifier,
+				target.peerInterfaceName)
 			if err != nil {
-				return fmt.Errorf("could not find veth peer for interface %s in netns %s, err: %q",
-					interfaceName, namespace, err)
+				return nil, err
 			}
-			logger.Debug("cmdAdd", "step", "found netnsID and peerInterfaceName", "netnsID", netnsID,
-				"peerInterfaceName", peerInterfaceName)
-			// Set ethtool parameters in the global namespace, one by one.
-			for parameter, setting := range peerSettings {
-				logger.Debug("cmdAdd", "step", "ethtool set parameter inside global namespace",
-					"peerInterfaceName", peerInterfaceName, "parameter", parameter, "setting", setting)
-				if _, err := ethtool.Set(peerInterfaceName, parameter, setting); err != nil {
-					return err
-				}
+			errs = append(errs, e...)
+			e, err = ethtoolConfigs.ValidateFlowRules(backend, interfaceName, target.peerClassifier,
+				target.peerInterfaceName)
+			if err != nil {
+				return nil, err
 			}
+			errs = append(errs, e...)
 		}
 	}
-	logger.Debug("cmdAdd", "done", true)
-	// Pass through the result for the next plugin
-	return types.PrintResult(prevResult, conf.CNIVersion)
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+		return nil, fmt.Errorf("provided ethtool configuration is not valid: %w", errs)
+	}
+	return targets, nil
+}
+
+// resolvedInterface is an interface inside the sandbox together with its namespace and, if requested, its peer in
+// the global namespace. The peer is the veth peer of a veth interface, the lower device of a macvlan, ipvlan or VLAN
+// interface and the physical function of an SR-IOV virtual function. peerClassifier is the classifier that configures
+// the peer, "peer" or "parent".
+type resolvedInterface struct {
+	interfaceName      string
+	interfaceIndex     int
+	namespace          string
+	netns              ns.NetNS
+	linkType           string
+	peerClassifier     string
+	peerInterfaceName  string
+	peerInterfaceIndex int
+	// vfIndex is the index of the interface among the virtual functions of its peer, or -1 if it is not an SR-IOV
+	// virtual function.
+	vfIndex int
+}
+
+// closeTargets closes the namespaces of targets.
+func closeTargets(targets map[string]*resolvedInterface) {
+	for _, target := range targets {
+		target.netns.Close()
+	}
+}
+
+// resolveInterface looks up the namespace and index of interfaceName in the list of interfaces of the previous result.
+// If ethtoolConfig configures "peer" or "parent", it also looks up the interface in the global namespace that
+// interfaceName is attached to and checks that the classifier and its settings fit the type of interfaceName. The
+// caller must close the namespace of the returned interface.
+func resolveInterface(logger *customLogger, cmd string, interfaces []*types100.Interface, interfaceName string,
+	ethtoolConfig ethtool.EthtoolConfig) (_ *resolvedInterface, err error) {
+	// Get the namespace name and the netns.
+	namespace, err := helpers.ExtractInterfaceNamespace(interfaces, interfaceName)
+	if err != nil {
+		return nil, err
+	}
+	netns, err := ns.GetNS(namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			netns.Close()
+		}
+	}()
+
+	// Get the interface index of the interface inside the namespace (e.g. "eth0" has index "2").
+	var interfaceIndex int
+	err = netns.Do(func(_ ns.NetNS) error {
+		var err error
+		interfaceIndex, err = helpers.GetInterfaceIndex(interfaceName)
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug(cmd, "step", "found interface namespace and index", "interfaceName", interfaceName,
+		"namespace", namespace, "interfaceIndex", interfaceIndex)
+
+	target := &resolvedInterface{
+		interfaceName:  interfaceName,
+		interfaceIndex: interfaceIndex,
+		namespace:      namespace,
+		netns:          netns,
+		vfIndex:        -1,
+	}
+	switch {
+	case ethtoolConfig.GetPeer() != nil:
+		target.peerClassifier = ethtool.PeerClassifier
+	case ethtoolConfig.GetParent() != nil:
+		target.peerClassifier = ethtool.ParentClassifier
+	default:
+		return target, nil
+	}
+
+	// Find the peer in the global namespace. The "peer" or "parent" index. Ask the kernel instead of relying on the
+	// previous result, because not all plugins report the host side of an interface.
+	linkType, hostLink, err := helpers.FindHostLink(netns, interfaceName)
+	if err != nil {
+		return nil, fmt.Errorf("could not find %s for interface %s in netns %s, err: %q", target.peerClassifier,
+			interfaceName, namespace, err)
+	}
+	target.linkType = linkType
+	target.peerInterfaceName = hostLink.Name
+	target.peerInterfaceIndex = hostLink.Index
+	target.vfIndex = hostLink.VFIndex
+	if linkType == helpers.TypeVeth && target.peerClassifier == ethtool.ParentClassifier {
+		return nil, fmt.Errorf("interface %s is a veth interface without a parent, use %q for its veth peer %s",
+			interfaceName, ethtool.PeerClassifier, target.peerInterfaceName)
+	}
+	if settings := ethtoolConfig.Get(target.peerClassifier); settings.VF != nil && target.vfIndex < 0 {
+		return nil, fmt.Errorf("interface %s of type %s is not an SR-IOV virtual function, section \"vf\" of %q "+
+			"does not apply", interfaceName, linkType, target.peerClassifier)
+	}
+	logger.Debug(cmd, "step", "found peerInterfaceName", "peerInterfaceName", target.peerInterfaceName,
+		"peerInterfaceIndex", target.peerInterfaceIndex, "linkType", linkType, "classifier", target.peerClassifier,
+		"vfIndex", target.vfIndex)
+	return target, nil
+}
+
+// cmdCheck is called for CHECK requests. It reads the current state of every configured parameter, inside the
+// sandbox and on its peer, and fails if any of them differs from the configuration.
+func cmdCheck(args *skel.CmdArgs) error {
+	conf, err := parseConfig(args.StdinData)
+	if err != nil {
+		return err
+	}
+	logger, err := newCustomLogger(conf)
+	if err != nil {
+		return err
+	}
+	logger.Debug("cmdCheck", "conf", conf, "conf.Logfile", conf.LogFile, "conf.Debug", conf.Debug)
+
+	backend, err := ethtool.NewBackend(conf.Backend)
+	if err != nil {
+		return err
+	}
+
+	// This plugin must be called as a chained plugin.
+	if conf.PrevResult == nil {
+		return fmt.Errorf("must be called as chained plugin")
+	}
+	prevResult, err := types100.GetResult(conf.PrevResult)
+	if err != nil {
+		return fmt.Errorf("failed to convert prevResult: %v", err)
+	}
+	logger.Debug("cmdCheck", "prevResult", prevResult)
+
+	ethtoolConfigs, _, err := matchInterfaces(logger, "cmdCheck", conf, args.Args, prevResult.Interfaces)
+	if err != nil {
+		return err
+	}
+
+	store := state.New(conf.StateDir)
+	var mismatches []string
+	for interfaceName, ethtoolConfig := range ethtoolConfigs {
+		target, err := resolveInterface(logger, "cmdCheck", prevResult.Interfaces, interfaceName, ethtoolConfig)
+		if err != nil {
+			return err
+		}
+		defer target.netns.Close()
+		record, err := store.Load(args.ContainerID, interfaceName)
+		if err != nil {
+			return err
+		}
+		m, err := findInterfaceMismatches(backend, ethtoolConfig, target, record)
+		if err != nil {
+			return err
+		}
+		mismatches = append(mismatches, m...)
+	}
+	if len(mismatches) > 0 {
+		sort.Strings(mismatches)
+		logger.Info("cmdCheck", "mismatches", mismatches)
+		return types.NewError(types.ErrInternal, "ethtool settings do not match the configuration",
+			strings.Join(mismatches, "; "))
+	}
+	logger.Debug("cmdCheck", "done", true)
+	return nil
+}
+
+// findInterfaceMismatches compares the current state of target and of its peer with ethtoolConfig. record holds the
+// IDs of the RSS contexts that cmdAdd created, it is nil if cmdAdd did not record anything. It returns a description
+// of every parameter whose state differs.
+func findInterfaceMismatches(backend ethtool.Backend, ethtoolConfig ethtool.EthtoolConfig,
+	target *resolvedInterface, record *state.Record) ([]string, error) {
+	if record == nil {
+		record = &state.Record{}
+	}
+	var mismatches []string
+	err := target.netns.Do(func(_ ns.NetNS) error {
+		var err error
+		mismatches, err = findMismatches(backend, target.interfaceName, ethtool.SelfClassifier, ethtoolConfig.GetSelf(),
+			record.SelfRSSContexts, record.SelfFlowRules)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if target.peerClassifier == "" {
+		return mismatches, nil
+	}
+	peerSettings := ethtoolConfig.Get(target.peerClassifier)
+	m, err := findMismatches(backend, target.peerInterfaceName, target.peerClassifier, peerSettings,
+		record.PeerRSSContexts, record.PeerFlowRules)
+	if err != nil {
+		return nil, err
+	}
+	mismatches = append(mismatches, m...)
+	if peerSettings.VF.IsEmpty() {
+		return mismatches, nil
+	}
+	differences, err := ethtool.CompareVF(target.peerInterfaceName, target.vfIndex, peerSettings.VF)
+	if err != nil {
+		return nil, err
+	}
+	for _, difference := range differences {
+		mismatches = append(mismatches, fmt.Sprintf("interface %s (%s): %s", target.peerInterfaceName,
+			target.peerClassifier, difference))
+	}
+	return mismatches, nil
+}
+
+// findMismatches compares the current state of interface interfaceName with settings. rssContexts are the IDs of the
+// RSS contexts and flowRules are the flow rules that cmdAdd created on interfaceName. It returns a description of every
+// parameter whose state differs.
+func findMismatches(backend ethtool.Backend, interfaceName, classifier string, settings *ethtool.Settings,
+	rssContexts []uint32, flowRules []ethtool.FlowRule) ([]string, error) {
+	differences, err := ethtool.Compare(backend, interfaceName, settings)
+	if err != nil {
+		return nil, err
+	}
+	if settings != nil && settings.RSS != nil && len(settings.RSS.Contexts) > 0 {
+		d, err := ethtool.CompareRSSContexts(backend, interfaceName, settings.RSS.Contexts, rssContexts)
+		if err != nil {
+			return nil, err
+		}
+		differences = append(differences, d...)
+	}
+	if settings != nil && len(settings.FlowRules) > 0 {
+		d, err := ethtool.CompareFlowRules(backend, interfaceName, settings.FlowRules, flowRules)
+		if err != nil {
+			return nil, err
+		}
+		differences = append(differences, d...)
+	}
+	var mismatches []string
+	for _, difference := range differences {
+		mismatches = append(mismatches, fmt.Sprintf("interface %s (%s): %s", interfaceName, classifier, difference))
+	}
+	return mismatches, nil
+}
+
+// cmdDel is called for DELETE requests. It restores the original state of all interfaces that cmdAdd modified for
+// this container. Interfaces and namespaces that no longer exist are skipped.
+func cmdDel(args *skel.CmdArgs) error {
+	conf, err := parseDelConfig(args.StdinData)
+	if err != nil {
+		return err
+	}
+	logger, err := newCustomLogger(conf)
+	if err != nil {
+		return err
+	}
+	logger.Debug("cmdDel", "conf", conf, "containerID", args.ContainerID, "netns", args.Netns)
+
+	store := state.New(conf.StateDir)
+	records, err := store.List(args.ContainerID)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		logger.Debug("cmdDel", "step", "nothing to restore")
+		return nil
+	}
+
+	backend, err := ethtool.NewBackend(conf.Backend)
+	if err != nil {
+		return err
+	}
+	// A record that cannot be restored must not keep the other records from being restored. It stays in the store,
+	// so that the runtime can retry DEL.
+	var errs []error
+	for _, record := range records {
+		logger.Debug("cmdDel", "step", "restoring original state", "record", record)
+		selfErr := restoreSelf(logger, backend, args.Netns, record)
+		peerErr := restorePeer(logger, backend, record)
+		if err := errors.Join(selfErr, peerErr); err != nil {
+			logger.Info("cmdDel", "step", "could not restore original state", "interfaceName", record.InterfaceName,
+				"err", err.Error())
+			errs = append(errs, fmt.Errorf("interface %s: %w", record.InterfaceName, err))
+			continue
+		}
+		if err := store.Delete(record.ContainerID, record.InterfaceName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	logger.Debug("cmdDel", "done", true)
+	return nil
+}
+
+// restoreSelf restores the original state of the interface inside the sandbox. It is a no-op if the namespace or the
+// interface are already gone.
+func restoreSelf(logger *customLogger, backend ethtool.Backend, namespace string, record *state.Record) error {
+	if namespace == "" ||
+		(record.Self.IsEmpty() && len(record.SelfRSSContexts) == 0 && len(record.SelfFlowRules) == 0) {
+		return nil
+	}
+	netns, err := ns.GetNS(namespace)
+	if err != nil {
+		var notExistErr ns.NSPathNotExistErr
+		var notNSErr ns.NSPathNotNSErr
+		if errors.As(err, &notExistErr) || errors.As(err, &notNSErr) {
+			logger.Debug("cmdDel", "step", "namespace is gone, nothing to restore", "namespace", namespace)
+			return nil
+		}
+		return err
+	}
+	defer netns.Close()
+	return netns.Do(func(_ ns.NetNS) error {
+		if _, err := helpers.GetInterfaceIndex(record.InterfaceName); err != nil {
+			if helpers.IsLinkNotFound(err) {
+				logger.Debug("cmdDel", "step", "interface is gone, nothing to restore", "namespace", namespace,
+					"interfaceName", record.InterfaceName)
+				return nil
+			}
+			return err
+		}
+		logger.Debug("cmdDel", "step", "ethtool restore parameters inside namespace", "namespace", namespace,
+			"interfaceName", record.InterfaceName, "settings", record.Self, "rssContexts", record.SelfRSSContexts,
+			"flowRules", record.SelfFlowRules)
+		if err := ethtool.DeleteFlowRules(backend, record.InterfaceName, record.SelfFlowRules); err != nil {
+			return err
+		}
+		if err := ethtool.DeleteRSSContexts(backend, record.InterfaceName, record.SelfRSSContexts); err != nil {
+			return err
+		}
+		return ethtool.Restore(backend, record.InterfaceName, record.Self)
+	})
+}
+
+// restorePeer restores the original state of the peer in the global namespace. It is a no-op if the peer is
+// gone or if its name now belongs to a different interface.
+func restorePeer(logger *customLogger, backend ethtool.Backend, record *state.Record) error {
+	if record.PeerInterfaceName == "" ||
+		(record.Peer.IsEmpty() && len(record.PeerRSSContexts) == 0 && len(record.PeerFlowRules) == 0) {
+		return nil
+	}
+	index, err := helpers.GetInterfaceIndex(record.PeerInterfaceName)
+	if err != nil {
+		if helpers.IsLinkNotFound(err) {
+			logger.Debug("cmdDel", "step", "peer interface is gone, nothing to restore",
+				"peerInterfaceName", record.PeerInterfaceName)
+			return nil
+		}
+		return err
+	}
+	if index != record.PeerInterfaceIndex {
+		logger.Debug("cmdDel", "step", "peer interface was recreated, nothing to restore",
+			"peerInterfaceName", record.PeerInterfaceName, "peerInterfaceIndex", index,
+			"recordedPeerInterfaceIndex", record.PeerInterfaceIndex)
+		return nil
+	}
+	logger.Debug("cmdDel", "step", "ethtool restore parameters inside global namespace",
+		"peerInterfaceName", record.PeerInterfaceName, "settings", record.Peer, "rssContexts", record.PeerRSSContexts,
+		"flowRules", record.PeerFlowRules)
+	if err := ethtool.DeleteFlowRules(backend, record.PeerInterfaceName, record.PeerFlowRules); err != nil {
+		return err
+	}
+	if err := ethtool.DeleteRSSContexts(backend, record.PeerInterfaceName, record.PeerRSSContexts); err != nil {
+		return err
+	}
+	return restoreSettings(backend, record.PeerInterfaceName, record.VFIndex, record.Peer)
 }
 
 func main() {
-	skel.PluginMainFuncs(skel.CNIFuncs{Add: cmdAdd}, version.All, bv.BuildString("cni-ethtool"))
+	skel.PluginMainFuncs(skel.CNIFuncs{Add: cmdAdd, Check: cmdCheck, Del: cmdDel}, version.All, bv.BuildString("cni-ethtool"))
 }
@@ -0,0 +1,82 @@
+package ethtool
+
+import (
+	"fmt"
+)
+
+const (
+	// BackendNetlink talks to the kernel through the ethtool generic netlink family. This is the default.
+	BackendNetlink = "netlink"
+	// BackendExec runs the ethtool binary, either from inside the container or from /host.
+	BackendExec = "exec"
+)
+
+// Backend reads and writes the ethtool settings of interfaces in the network namespace of the calling thread.
+type Backend interface {
+	// ListFeatures returns the offloading attributes of iface.
+	ListFeatures(iface string) (OffloadList, error)
+	// FeatureNames returns the kernel names of the features of iface, i.e. the ETH_SS_FEATURES string set.
+	FeatureNames(iface string) ([]string, error)
+	// SetFeatures changes the offloading attributes of iface.
+	SetFeatures(iface string, features map[string]bool) error
+	// GetPrivFlags returns all private flags of iface, i.e. the ETH_SS_PRIV_FLAGS string set, and whether they are
+	// enabled.
+	GetPrivFlags(iface string) (map[string]bool, error)
+	// SetPrivFlags changes the provided private flags of iface.
+	SetPrivFlags(iface string, privFlags map[string]bool) error
+	// GetRings returns the ring parameters of iface together with their maximums.
+	GetRings(iface string) (*RingParameters, error)
+	// SetRings changes the provided ring parameters of iface, keyed by their ethtool -G name.
+	SetRings(iface string, rings map[string]uint32) error
+	// GetChannels returns the channel counts of iface together with their maximums.
+	GetChannels(iface string) (*ChannelParameters, error)
+	// SetChannels changes the provided channel counts of iface, keyed by their ethtool -L name.
+	SetChannels(iface string, channels map[string]uint32) error
+	// GetCoalesce returns the coalescing parameters of iface. Parameters that iface does not support are nil.
+	GetCoalesce(iface string) (*Coalesce, error)
+	// SetCoalesce changes the coalescing parameters of iface that are set in coalesce.
+	SetCoalesce(iface string, coalesce *Coalesce) error
+	// GetPause returns the pause parameters of iface. Parameters that iface does not report are nil.
+	GetPause(iface string) (*Pause, error)
+	// SetPause changes the pause parameters of iface that are set in pause.
+	SetPause(iface string, pause *Pause) error
+	// GetLinkSettings returns the link settings of iface together with the link modes that it supports.
+	GetLinkSettings(iface string) (*LinkParameters, error)
+	// SetLinkSettings changes the link settings of iface that are set in link.
+	SetLinkSettings(iface string, link *Link) error
+	// GetRSS returns the RSS settings of RSS context context of iface together with the hash functions and the
+	// number of RX rings that it supports. Context 0 is the default context.
+	GetRSS(iface string, context uint32) (*RSSParameters, error)
+	// SetRSS changes the RSS settings of RSS context context of iface that are set in rss. rss.Default must not be
+	// set, see ResetRSSTable.
+	SetRSS(iface string, context uint32, rss *RSSContext) error
+	// ResetRSSTable resets the indirection table of the default RSS context of iface to the driver default, which
+	// spreads flows evenly over all RX rings and follows changes of the channel counts.
+	ResetRSSTable(iface string) error
+	// CreateRSSContext creates a new RSS context on iface with the RSS settings in rss and returns its ID.
+	CreateRSSContext(iface string, rss *RSSContext) (uint32, error)
+	// DeleteRSSContext deletes RSS context context of iface. It succeeds if the context does not exist, e.g. because
+	// an earlier attempt already deleted it, so that cmdDel can be retried.
+	DeleteRSSContext(iface string, context uint32) error
+	// GetFlowRule returns the flow rule at location loc of iface, or nil if the location is empty.
+	GetFlowRule(iface string, loc uint32) (*FlowRule, error)
+	// InsertFlowRule installs rule on iface and returns its location.
+	InsertFlowRule(iface string, rule *FlowRule) (uint32, error)
+	// DeleteFlowRule deletes the flow rule at location loc of iface. Like DeleteRSSContext, it succeeds if the rule
+	// does not exist.
+	DeleteFlowRule(iface string, loc uint32) error
+	// PFCEnabled returns true if priority flow control is enabled for any priority of iface.
+	PFCEnabled(iface string) (bool, error)
+}
+
+// NewBackend returns the backend with the provided name. An empty name selects BackendNetlink.
+func NewBackend(name string) (Backend, error) {
+	switch name {
+	case "", BackendNetlink:
+		return newNetlinkBackend()
+	case BackendExec:
+		return execBackend{}, nil
+	}
+	return nil, fmt.Errorf("unknown ethtool backend %q, valid backends are %q and %q", name, BackendNetlink,
+		BackendExec)
+}
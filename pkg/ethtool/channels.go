@@ -0,0 +1,92 @@
+package ethtool
+
+import (
+	"fmt"
+)
+
+const (
+	ChannelRX       = "rx"
+	ChannelTX       = "tx"
+	ChannelOther    = "other"
+	ChannelCombined = "combined"
+)
+
+// channelFields lists the channel parameters in the order of ethtool -L. label is the name in the output of ethtool
+// -l, attr and maxAttr are the ETHTOOL_A_CHANNELS_* attributes of the current count and of the maximum.
+var channelFields = []struct {
+	name    string
+	label   string
+	attr    uint16
+	maxAttr uint16
+}{
+	{ChannelRX, "RX", ethtoolAChannelsRXCount, ethtoolAChannelsRXMax},
+	{ChannelTX, "TX", ethtoolAChannelsTXCount, ethtoolAChannelsTXMax},
+	{ChannelOther, "Other", ethtoolAChannelsOtherCount, ethtoolAChannelsOtherMax},
+	{ChannelCombined, "Combined", ethtoolAChannelsCombinedCount, ethtoolAChannelsCombinedMax},
+}
+
+// Channels holds the channel counts of an interface, see ethtool -L. Counts that are not set are not changed.
+type Channels struct {
+	RX       *uint32 `json:"rx,omitempty"`
+	TX       *uint32 `json:"tx,omitempty"`
+	Other    *uint32 `json:"other,omitempty"`
+	Combined *uint32 `json:"combined,omitempty"`
+}
+
+// fields returns a pointer to each channel count field, keyed by its ethtool -L name.
+func (c *Channels) fields() map[string]**uint32 {
+	return map[string]**uint32{
+		ChannelRX:       &c.RX,
+		ChannelTX:       &c.TX,
+		ChannelOther:    &c.Other,
+		ChannelCombined: &c.Combined,
+	}
+}
+
+// IsEmpty returns true if no channel count is set.
+func (c *Channels) IsEmpty() bool {
+	for _, field := range c.fields() {
+		if *field != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// values returns each configured channel count, keyed by its ethtool -L name.
+func (c *Channels) values() map[string]uint32 {
+	values := map[string]uint32{}
+	for name, field := range c.fields() {
+		if *field != nil {
+			values[name] = **field
+		}
+	}
+	return values
+}
+
+// validate makes sure that every configured channel count is within the maximum that the device reports.
+func (c *Channels) validate(params *ChannelParameters) error {
+	values := c.values()
+	for _, field := range channelFields {
+		v, ok := values[field.name]
+		if !ok {
+			continue
+		}
+		maximum, ok := params.Max[field.name]
+		if !ok {
+			return fmt.Errorf("device does not support channel parameter %q", field.name)
+		}
+		if v > maximum {
+			return fmt.Errorf("channel count %d for %q exceeds the maximum %d of the device", v, field.name,
+				maximum)
+		}
+	}
+	return nil
+}
+
+// ChannelParameters are the channel counts that a device reports, keyed by their ethtool -L name. Parameters that the
+// device or the kernel does not support are missing.
+type ChannelParameters struct {
+	Current map[string]uint32
+	Max     map[string]uint32
+}
@@ -0,0 +1,45 @@
+package ethtool
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	ens3ChannelsOutput = `Channel parameters for ens3:
+Pre-set maximums:
+RX:		n/a
+TX:		n/a
+Other:		1
+Combined:	63
+Current hardware settings:
+RX:		n/a
+TX:		n/a
+Other:		1
+Combined:	8
+`
+)
+
+func TestExecBackendChannels(t *testing.T) {
+	fake := newFakeCommand(t, []string{"-l", "ens3"}, ens3ChannelsOutput, "-L", nil)
+
+	backend := execBackend{}
+	params, err := backend.GetChannels("ens3")
+	if err != nil {
+		t.Fatalf("GetChannels(ens3): expected to see no error but got %q", err)
+	}
+	expected := &ChannelParameters{
+		Current: map[string]uint32{ChannelOther: 1, ChannelCombined: 8},
+		Max:     map[string]uint32{ChannelOther: 1, ChannelCombined: 63},
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("GetChannels(ens3): expected %v but got %v", expected, params)
+	}
+	if err := backend.SetChannels("ens3", map[string]uint32{ChannelCombined: 16, ChannelOther: 1}); err != nil {
+		t.Fatalf("SetChannels(ens3): expected to see no error but got %q", err)
+	}
+	expectedParameters := [][]string{{"-L", "ens3", "other", "1", "combined", "16"}}
+	if !reflect.DeepEqual(fake.setParameters, expectedParameters) {
+		t.Fatalf("SetChannels(ens3): expected parameters %v but got %v", expectedParameters, fake.setParameters)
+	}
+}
@@ -0,0 +1,190 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	CoalesceRXUsecs    = "rx-usecs"
+	CoalesceRXFrames   = "rx-frames"
+	CoalesceTXUsecs    = "tx-usecs"
+	CoalesceTXFrames   = "tx-frames"
+	CoalesceAdaptiveRX = "adaptive-rx"
+	CoalesceAdaptiveTX = "adaptive-tx"
+	CoalesceCQEModeRX  = "cqe-mode-rx"
+	CoalesceCQEModeTX  = "cqe-mode-tx"
+)
+
+// coalesceFields lists the numeric coalescing parameters in the order of ethtool -C. attr is the
+// ETHTOOL_A_COALESCE_* attribute.
+var coalesceFields = []struct {
+	name string
+	attr uint16
+}{
+	{CoalesceRXUsecs, ethtoolACoalesceRXUsecs},
+	{CoalesceRXFrames, ethtoolACoalesceRXMaxFrames},
+	{CoalesceTXUsecs, ethtoolACoalesceTXUsecs},
+	{CoalesceTXFrames, ethtoolACoalesceTXMaxFrames},
+}
+
+// coalesceFlags lists the boolean coalescing parameters in the order of ethtool -C. label is the line in the output
+// of ethtool -c that shows the rx and tx flag side by side, e.g. "Adaptive RX: off  TX: off".
+var coalesceFlags = []struct {
+	name  string
+	label string
+	tx    bool
+	attr  uint16
+}{
+	{CoalesceAdaptiveRX, "Adaptive RX", false, ethtoolACoalesceUseAdaptiveRX},
+	{CoalesceAdaptiveTX, "Adaptive RX", true, ethtoolACoalesceUseAdaptiveTX},
+	{CoalesceCQEModeRX, "CQE mode RX", false, ethtoolACoalesceUseCQEModeRX},
+	{CoalesceCQEModeTX, "CQE mode RX", true, ethtoolACoalesceUseCQEModeTX},
+}
+
+// Coalesce holds the interrupt coalescing parameters of an interface, see ethtool -C. Parameters that are not set are
+// not changed. When a device reports its coalescing parameters, parameters that it does not support are nil.
+type Coalesce struct {
+	RXUsecs    *uint32 `json:"rx-usecs,omitempty"`
+	RXFrames   *uint32 `json:"rx-frames,omitempty"`
+	TXUsecs    *uint32 `json:"tx-usecs,omitempty"`
+	TXFrames   *uint32 `json:"tx-frames,omitempty"`
+	AdaptiveRX *bool   `json:"adaptive-rx,omitempty"`
+	AdaptiveTX *bool   `json:"adaptive-tx,omitempty"`
+	CQEModeRX  *bool   `json:"cqe-mode-rx,omitempty"`
+	CQEModeTX  *bool   `json:"cqe-mode-tx,omitempty"`
+}
+
+// numbers returns a pointer to each numeric parameter field, keyed by its ethtool -C name.
+func (c *Coalesce) numbers() map[string]**uint32 {
+	return map[string]**uint32{
+		CoalesceRXUsecs:  &c.RXUsecs,
+		CoalesceRXFrames: &c.RXFrames,
+		CoalesceTXUsecs:  &c.TXUsecs,
+		CoalesceTXFrames: &c.TXFrames,
+	}
+}
+
+// flags returns a pointer to each boolean parameter field, keyed by its ethtool -C name.
+func (c *Coalesce) flags() map[string]**bool {
+	return map[string]**bool{
+		CoalesceAdaptiveRX: &c.AdaptiveRX,
+		CoalesceAdaptiveTX: &c.AdaptiveTX,
+		CoalesceCQEModeRX:  &c.CQEModeRX,
+		CoalesceCQEModeTX:  &c.CQEModeTX,
+	}
+}
+
+// IsEmpty returns true if no coalescing parameter is set.
+func (c *Coalesce) IsEmpty() bool {
+	for _, field := range c.numbers() {
+		if *field != nil {
+			return false
+		}
+	}
+	for _, field := range c.flags() {
+		if *field != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Copy returns a deep copy of c.
+func (c *Coalesce) Copy() *Coalesce {
+	if c == nil {
+		return nil
+	}
+	copied := &Coalesce{}
+	dstNumbers := copied.numbers()
+	for name, field := range c.numbers() {
+		if *field != nil {
+			v := **field
+			*dstNumbers[name] = &v
+		}
+	}
+	dstFlags := copied.flags()
+	for name, field := range c.flags() {
+		if *field != nil {
+			v := **field
+			*dstFlags[name] = &v
+		}
+	}
+	return copied
+}
+
+// unsupported returns the names of the parameters that are set in c but that the device does not report in current.
+func (c *Coalesce) unsupported(current *Coalesce) []string {
+	var names []string
+	numbers, currentNumbers := c.numbers(), current.numbers()
+	for _, field := range coalesceFields {
+		if *numbers[field.name] != nil && *currentNumbers[field.name] == nil {
+			names = append(names, field.name)
+		}
+	}
+	flags, currentFlags := c.flags(), current.flags()
+	for _, field := range coalesceFlags {
+		if *flags[field.name] != nil && *currentFlags[field.name] == nil {
+			names = append(names, field.name)
+		}
+	}
+	return names
+}
+
+// merge copies the value of every parameter that is set in selection from current into c, unless c already holds it.
+func (c *Coalesce) merge(current, selection *Coalesce) {
+	numbers, currentNumbers := c.numbers(), current.numbers()
+	for name, field := range selection.numbers() {
+		if *field != nil && *numbers[name] == nil && *currentNumbers[name] != nil {
+			v := **currentNumbers[name]
+			*numbers[name] = &v
+		}
+	}
+	flags, currentFlags := c.flags(), current.flags()
+	for name, field := range selection.flags() {
+		if *field != nil && *flags[name] == nil && *currentFlags[name] != nil {
+			v := **currentFlags[name]
+			*flags[name] = &v
+		}
+	}
+}
+
+// diff returns a description of every parameter that is set in c and whose value in current differs.
+func (c *Coalesce) diff(current *Coalesce) []string {
+	var mismatches []string
+	currentNumbers := current.numbers()
+	for name, field := range c.numbers() {
+		if *field == nil {
+			continue
+		}
+		v := *currentNumbers[name]
+		if v == nil {
+			mismatches = append(mismatches, fmt.Sprintf("coalescing parameter %q is not supported", name))
+		} else if *v != **field {
+			mismatches = append(mismatches, fmt.Sprintf("coalescing parameter %q is %d, expected %d", name, *v,
+				**field))
+		}
+	}
+	currentFlags := current.flags()
+	for name, field := range c.flags() {
+		if *field == nil {
+			continue
+		}
+		v := *currentFlags[name]
+		if v == nil {
+			mismatches = append(mismatches, fmt.Sprintf("coalescing parameter %q is not supported", name))
+		} else if *v != **field {
+			mismatches = append(mismatches, fmt.Sprintf("coalescing parameter %q is %s, expected %s", name,
+				status[*v], status[**field]))
+		}
+	}
+	return mismatches
+}
+
+func (c Coalesce) String() string {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,62 @@
+package ethtool
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/pointer"
+)
+
+const (
+	ens3CoalesceOutput = `Coalesce parameters for ens3:
+Adaptive RX: on  TX: off
+stats-block-usecs:	n/a
+sample-interval:	n/a
+pkt-rate-low:		n/a
+pkt-rate-high:		n/a
+
+rx-usecs:	8
+rx-frames:	128
+rx-usecs-irq:	n/a
+rx-frames-irq:	n/a
+
+tx-usecs:	8
+tx-frames:	n/a
+tx-usecs-irq:	n/a
+tx-frames-irq:	n/a
+
+CQE mode RX: n/a  TX: n/a
+
+`
+)
+
+func TestExecBackendCoalesce(t *testing.T) {
+	fake := newFakeCommand(t, []string{"-c", "ens3"}, ens3CoalesceOutput, "-C", nil)
+
+	backend := execBackend{}
+	coalesce, err := backend.GetCoalesce("ens3")
+	if err != nil {
+		t.Fatalf("GetCoalesce(ens3): expected to see no error but got %q", err)
+	}
+	expected := &Coalesce{
+		RXUsecs:    pointer.Uint32(8),
+		RXFrames:   pointer.Uint32(128),
+		TXUsecs:    pointer.Uint32(8),
+		AdaptiveRX: pointer.Bool(true),
+		AdaptiveTX: pointer.Bool(false),
+	}
+	if !reflect.DeepEqual(coalesce, expected) {
+		t.Fatalf("GetCoalesce(ens3): expected %v but got %v", expected, coalesce)
+	}
+	if err := backend.SetCoalesce("ens3", &Coalesce{
+		TXUsecs:    pointer.Uint32(16),
+		RXUsecs:    pointer.Uint32(4),
+		AdaptiveRX: pointer.Bool(false),
+	}); err != nil {
+		t.Fatalf("SetCoalesce(ens3): expected to see no error but got %q", err)
+	}
+	expectedParameters := [][]string{{"-C", "ens3", "rx-usecs", "4", "tx-usecs", "16", "adaptive-rx", "off"}}
+	if !reflect.DeepEqual(fake.setParameters, expectedParameters) {
+		t.Fatalf("SetCoalesce(ens3): expected parameters %v but got %v", expectedParameters, fake.setParameters)
+	}
+}
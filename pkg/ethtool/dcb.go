@@ -0,0 +1,117 @@
+package ethtool
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// Constants of the DCB rtnetlink interface, see include/uapi/linux/dcbnl.h.
+const (
+	dcbCmdGState  = 1
+	dcbCmdPFCGCfg = 7
+	dcbCmdIEEEGet = 21
+
+	dcbAttrIfname  = 1
+	dcbAttrState   = 2
+	dcbAttrPFCCfg  = 4
+	dcbAttrIEEE    = 13
+	dcbAttrIEEEPFC = 2
+
+	dcbPFCUpAttr0   = 1
+	dcbPFCUpAttr7   = 8
+	dcbPFCUpAttrAll = 9
+
+	// ieeePFCEnabledOffset is the offset of pfc_en, the bitmap of the priorities with PFC, in struct ieee_pfc.
+	ieeePFCEnabledOffset = 1
+)
+
+// dcbMsg is struct dcbmsg, the header of all DCB rtnetlink messages.
+type dcbMsg struct {
+	cmd uint8
+}
+
+func (m dcbMsg) Len() int {
+	return 4
+}
+
+func (m dcbMsg) Serialize() []byte {
+	return []byte{unix.AF_UNSPEC, m.cmd, 0, 0}
+}
+
+// pfcEnabled returns true if priority flow control is enabled for any priority of interface iface in the network
+// namespace of the calling thread. It reads the IEEE 802.1Qaz configuration and falls back to the CEE configuration
+// for drivers that only implement the latter. Interfaces whose driver does not implement DCB never have PFC enabled.
+// The ethtool binary cannot read DCB settings, so both backends use this function.
+func pfcEnabled(iface string) (bool, error) {
+	attrs, err := dcbRequest(dcbCmdIEEEGet, iface)
+	if errors.Is(err, unix.EOPNOTSUPP) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not get IEEE DCB configuration of interface %q, err: %q", iface, err)
+	}
+	if ieee := attrs.get(dcbAttrIEEE); ieee != nil {
+		ieeeAttrs, err := parseAttributes(ieee)
+		if err != nil {
+			return false, err
+		}
+		if pfc := ieeeAttrs.get(dcbAttrIEEEPFC); len(pfc) > ieeePFCEnabledOffset {
+			return pfc[ieeePFCEnabledOffset] != 0, nil
+		}
+	}
+
+	attrs, err = dcbRequest(dcbCmdGState, iface)
+	if errors.Is(err, unix.EOPNOTSUPP) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not get DCB state of interface %q, err: %q", iface, err)
+	}
+	if state, ok := attrs.lookupUint8(dcbAttrState); !ok || state == 0 {
+		return false, nil
+	}
+	pfcCfg := nl.NewRtAttr(dcbAttrPFCCfg|int(nl.NLA_F_NESTED), nil)
+	pfcCfg.AddRtAttr(dcbPFCUpAttrAll, nil)
+	attrs, err = dcbRequest(dcbCmdPFCGCfg, iface, pfcCfg)
+	if errors.Is(err, unix.EOPNOTSUPP) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not get PFC configuration of interface %q, err: %q", iface, err)
+	}
+	priorities, err := parseAttributes(attrs.get(dcbAttrPFCCfg))
+	if err != nil {
+		return false, err
+	}
+	for attr := uint16(dcbPFCUpAttr0); attr <= dcbPFCUpAttr7; attr++ {
+		if v, ok := priorities.lookupUint8(attr); ok && v != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// dcbRequest sends DCB command cmd with the provided attributes for interface iface and returns the attributes of the
+// reply.
+func dcbRequest(cmd uint8, iface string, attrs ...*nl.RtAttr) (attributes, error) {
+	req := nl.NewNetlinkRequest(unix.RTM_GETDCB, 0)
+	req.AddData(dcbMsg{cmd: cmd})
+	req.AddData(nl.NewRtAttr(dcbAttrIfname, nl.ZeroTerminated(iface)))
+	for _, attr := range attrs {
+		req.AddData(attr)
+	}
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_GETDCB)
+	if err != nil {
+		return nil, netlinkError{err}
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("unexpected number of replies for DCB command %d", cmd)
+	}
+	if len(msgs[0]) < (dcbMsg{}).Len() {
+		return nil, fmt.Errorf("received truncated DCB message")
+	}
+	return parseAttributes(msgs[0][(dcbMsg{}).Len():])
+}
@@ -2,6 +2,8 @@ package ethtool
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 
 	"github.com/andreaskaris/cni-ethtool/pkg/helpers"
 )
@@ -9,6 +11,16 @@ import (
 const (
 	SelfClassifier = "self"
 	PeerClassifier = "peer"
+	// ParentClassifier targets the lower device of a macvlan, macvtap, ipvlan, ipvtap or VLAN interface, or the
+	// physical function of an SR-IOV virtual function. For these types, "peer" refers to the same device.
+	ParentClassifier = "parent"
+
+	// ConfigVersionLegacy is the version of configurations without "configVersion". They may list offloading
+	// attributes directly, e.g. {"self": {"tx-checksumming": false}}.
+	ConfigVersionLegacy = ""
+	// ConfigVersionV1 requires every setting to be part of its section, e.g.
+	// {"self": {"features": {"tx-checksumming": false}}}.
+	ConfigVersionV1 = "v1"
 )
 
 var (
@@ -18,35 +30,85 @@ var (
 	}
 )
 
-type EthtoolConfig map[string]map[string]bool
+// EthtoolConfig holds the settings of one interface: "self" for the interface inside the sandbox and, optionally,
+// either "peer" or "parent" for the interface in the global namespace that it is attached to. "peer" is the veth peer
+// of a veth interface, the lower device of a macvlan, ipvlan or VLAN interface and the physical function of an SR-IOV
+// virtual function. "parent" is only valid for the latter types. "profile" selects a named bundle of settings that
+// the classifiers of the interface override, see Profiles.
+type EthtoolConfig struct {
+	Profile string    `json:"profile,omitempty"`
+	Self    *Settings `json:"self,omitempty"`
+	Peer    *Settings `json:"peer,omitempty"`
+	Parent  *Settings `json:"parent,omitempty"`
+	// unknownClassifiers holds all keys other than the known classifiers, so that IsValid can reject them.
+	unknownClassifiers []string
+	// key is the key of EthtoolConfigs that selected the interface, see EthtoolConfigs.Match.
+	key string
+}
 
-func (e EthtoolConfig) GetSelf() map[string]bool {
-	self, ok := e[SelfClassifier]
-	if ok {
-		return self
+func (e *EthtoolConfig) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	config := EthtoolConfig{}
+	for classifier, value := range raw {
+		var target **Settings
+		switch classifier {
+		case profileKey:
+			if err := json.Unmarshal(value, &config.Profile); err != nil {
+				return fmt.Errorf("invalid value %s for %q, expected a string", value, classifier)
+			}
+			continue
+		case SelfClassifier:
+			target = &config.Self
+		case PeerClassifier:
+			target = &config.Peer
+		case ParentClassifier:
+			target = &config.Parent
+		default:
+			config.unknownClassifiers = append(config.unknownClassifiers, classifier)
+			continue
+		}
+		settings := &Settings{}
+		if err := json.Unmarshal(value, settings); err != nil {
+			return fmt.Errorf("invalid settings for %q, err: %w", classifier, err)
+		}
+		*target = settings
 	}
+	sort.Strings(config.unknownClassifiers)
+	*e = config
 	return nil
 }
 
-func (e EthtoolConfig) GetPeer() map[string]bool {
-	self, ok := e[PeerClassifier]
-	if ok {
-		return self
+func (e EthtoolConfig) GetSelf() *Settings {
+	return e.Self
+}
+
+func (e EthtoolConfig) GetPeer() *Settings {
+	return e.Peer
+}
+
+func (e EthtoolConfig) GetParent() *Settings {
+	return e.Parent
+}
+
+// Get returns the settings of the provided classifier, or nil.
+func (e EthtoolConfig) Get(classifier string) *Settings {
+	switch classifier {
+	case SelfClassifier:
+		return e.Self
+	case PeerClassifier:
+		return e.Peer
+	case ParentClassifier:
+		return e.Parent
 	}
 	return nil
 }
 
+// IsValid returns true if e passes Validate. Use Validate to learn about the problems.
 func (e EthtoolConfig) IsValid() bool {
-	if len(e) == 2 {
-		_, ok1 := e[SelfClassifier]
-		_, ok2 := e[PeerClassifier]
-		return ok1 && ok2
-	}
-	if len(e) == 1 {
-		_, ok := e[SelfClassifier]
-		return ok
-	}
-	return false
+	return len(e.validate(configPath, ConfigVersionLegacy, true)) == 0
 }
 
 func (e EthtoolConfig) String() string {
@@ -57,16 +119,13 @@ func (e EthtoolConfig) String() string {
 	return string(b)
 }
 
+// EthtoolConfigs holds the configuration of every interface. Keys are interface names or patterns, see Match.
 type EthtoolConfigs map[string]EthtoolConfig
 
+// IsValid returns true if es passes Validate for configurations without a version. Use Validate to learn about the
+// problems.
 func (es EthtoolConfigs) IsValid() bool {
-	for _, ethtoolConfig := range es {
-		if !ethtoolConfig.IsValid() {
-			return false
-		}
-
-	}
-	return true
+	return len(es.Validate(ConfigVersionLegacy)) == 0
 }
 
 func (es EthtoolConfigs) String() string {
@@ -77,6 +136,46 @@ func (es EthtoolConfigs) String() string {
 	return string(b)
 }
 
+// List returns the offloading attributes of an interface, as reported by ethtool --json -k.
+func List(iface string) (OffloadList, error) {
+	out, err := ethtool("--json", "-k", iface)
+	if err != nil {
+		return nil, err
+	}
+	var ifaces []map[string]json.RawMessage
+	if err := json.Unmarshal(out, &ifaces); err != nil {
+		return nil, fmt.Errorf("could not parse ethtool output for interface %q, err: %q", iface, err)
+	}
+	if len(ifaces) != 1 {
+		return nil, fmt.Errorf("unexpected ethtool output for interface %q: %s", iface, out)
+	}
+	offloadList := OffloadList{}
+	for field, raw := range ifaces[0] {
+		if field == "ifname" {
+			continue
+		}
+		var offload Offload
+		if err := json.Unmarshal(raw, &offload); err != nil || offload.Active == nil {
+			return nil, fmt.Errorf("could not parse offloading attribute %q of interface %q: %s", field, iface, raw)
+		}
+		offloadList[field] = offload
+	}
+	return offloadList, nil
+}
+
+// Get returns the active state of the offloading attribute of an interface.
+func Get(iface, field string) (bool, error) {
+	offloadList, err := List(iface)
+	if err != nil {
+		return false, err
+	}
+	offload, ok := offloadList[field]
+	if !ok {
+		return false, fmt.Errorf("interface %q has no offloading attribute %q", iface, field)
+	}
+	return offload.IsActive(), nil
+}
+
 // Set sets the offloading attribute of an interface.
 func Set(iface, field string, enable bool) ([]byte, error) {
 	return ethtool("-K", iface, field, status[enable])
@@ -1,7 +1,9 @@
 package ethtool
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -434,6 +436,42 @@ var (
 	}
 )
 
+// fakeCommand replaces ethtool for the tests of execBackend. It answers command get with output and records every
+// command that starts with option set for interface ens3. Such a command prints the value of the first key of replies
+// that the command starts with, if any.
+type fakeCommand struct {
+	get           []string
+	output        string
+	set           string
+	replies       map[string]string
+	setParameters [][]string
+}
+
+// newFakeCommand replaces ethtool with a fakeCommand until the end of test t.
+func newFakeCommand(t *testing.T, get []string, output, set string, replies map[string]string) *fakeCommand {
+	f := &fakeCommand{get: get, output: output, set: set, replies: replies}
+	ethtool = f.run
+	t.Cleanup(func() { ethtool = fakeEthtool })
+	return f
+}
+
+func (f *fakeCommand) run(parameters ...string) ([]byte, error) {
+	if reflect.DeepEqual(parameters, f.get) {
+		return []byte(f.output), nil
+	}
+	if len(parameters) > 2 && parameters[0] == f.set && parameters[1] == "ens3" {
+		f.setParameters = append(f.setParameters, parameters)
+		command := strings.Join(parameters, " ")
+		for prefix, reply := range f.replies {
+			if strings.HasPrefix(command, prefix) {
+				return []byte(reply), nil
+			}
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported input for fake ethtool")
+}
+
 func TestList(t *testing.T) {
 	ethtool = fakeEthtool
 	tcs := []struct {
@@ -485,3 +523,209 @@ func TestSet(t *testing.T) {
 		}
 	}
 }
+
+func TestGet(t *testing.T) {
+	ethtool = fakeEthtool
+	tcs := []struct {
+		iface    string
+		field    string
+		expected bool
+		errStr   string
+	}{
+		{"dummy0", "tx-checksumming", true, ""},
+		{"dummy0", "rx-checksumming", false, ""},
+		{"dummy0", "tx-checksuming", false, "has no offloading attribute"},
+		{"dummy10", "tx-checksumming", false, "No such device"},
+	}
+	for _, tc := range tcs {
+		enabled, err := Get(tc.iface, tc.field)
+		if tc.errStr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.errStr) {
+				t.Fatalf("Get(%s, %s): expected to see error %q but got %q", tc.iface, tc.field, tc.errStr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Get(%s, %s): expected to see no error but got %q", tc.iface, tc.field, err)
+		}
+		if enabled != tc.expected {
+			t.Fatalf("Get(%s, %s): expected %t but got %t", tc.iface, tc.field, tc.expected, enabled)
+		}
+	}
+}
+
+func TestExecBackend(t *testing.T) {
+	ethtool = fakeEthtool
+	backend, err := NewBackend(BackendExec)
+	if err != nil {
+		t.Fatalf("NewBackend: expected to see no error but got %q", err)
+	}
+	offloadList, err := backend.ListFeatures("dummy0")
+	if err != nil {
+		t.Fatalf("ListFeatures(dummy0): expected to see no error but got %q", err)
+	}
+	if !offloadList.Equals(dummy0OutputParsed) {
+		t.Fatalf("ListFeatures(dummy0): unexpected differences %v", offloadList.Diff(dummy0OutputParsed))
+	}
+	if err := backend.SetFeatures("dummy0", map[string]bool{"tx-checksumming": false}); err != nil {
+		t.Fatalf("SetFeatures(dummy0): expected to see no error but got %q", err)
+	}
+	if err := backend.SetFeatures("dummy10", map[string]bool{"tx-checksumming": false}); err == nil {
+		t.Fatalf("SetFeatures(dummy10): expected to see an error")
+	}
+	if _, err := NewBackend("ioctl"); err == nil {
+		t.Fatalf("NewBackend(ioctl): expected to see an error")
+	}
+}
+
+func TestOffloadListDiff(t *testing.T) {
+	a := OffloadList{
+		"tx-checksumming": {pointer.Bool(true), nil, nil},
+		"highdma":         {pointer.Bool(true), pointer.Bool(false), pointer.Bool(true)},
+		"rx-all":          {pointer.Bool(false), pointer.Bool(true), pointer.Bool(false)},
+	}
+	b := OffloadList{
+		"tx-checksumming": {pointer.Bool(true), nil, nil},
+		"highdma":         {pointer.Bool(false), pointer.Bool(false), pointer.Bool(true)},
+		"loopback":        {pointer.Bool(false), pointer.Bool(true), pointer.Bool(false)},
+	}
+	diffs := a.Diff(b)
+	if len(diffs) != 3 {
+		t.Fatalf("Diff: expected 3 differences but got %v", diffs)
+	}
+	if diffs[0].Name != "highdma" || diffs[0].Old == nil || diffs[0].New == nil ||
+		diffs[1].Name != "loopback" || diffs[1].Old != nil ||
+		diffs[2].Name != "rx-all" || diffs[2].New != nil {
+		t.Fatalf("Diff: unexpected differences %v", diffs)
+	}
+	if a.Equals(b) || !a.Equals(a) {
+		t.Fatalf("Equals: unexpected result for %v and %v", a, b)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tcs := []struct {
+		in       string
+		version  string
+		expected ValidationErrors
+	}{
+		{`{"eth0": {"self": {"tx-checksumming": false}}}`, ConfigVersionLegacy, nil},
+		{`{"eth0": {"self": {"features": {"tx-checksumming": false}}, "peer": {"rx-checksumming": true}}}`,
+			ConfigVersionLegacy, nil},
+		{`{"eth0": {"self": {"features": {"rx-gro": false}}, "peer": {"rings": {"rx": 512}}}}`,
+			ConfigVersionV1, nil},
+		{`{"eth0": {"self": {"features": {"tx-checksumming": false}}, "peer": {"rx-checksumming": true}}}`,
+			ConfigVersionV1, ValidationErrors{{
+				Path:  "ethtool.eth0.peer.rx-checksumming",
+				Value: `"rx-checksumming"`,
+				Rule:  `offloading attributes must be part of section "features" in configuration version v1`,
+			}}},
+		{`{"eth0": {"self": {"features": {"tx-checksumming": false}}}}`, "v2", ValidationErrors{{
+			Path:  "configVersion",
+			Value: `"v2"`,
+			Rule:  `must be omitted or one of ["v1"]`,
+		}}},
+		{`{"eth0": {"peer": {"features": {"tx-checksumming": false}}}}`, ConfigVersionV1, ValidationErrors{{
+			Path:  "ethtool.eth0",
+			Value: `{"peer":{"features":{"tx-checksumming":false}}}`,
+			Rule:  `must contain "self"`,
+		}}},
+		{`{"eth0": {"self": {"features": {"tx-checksuming": true}}, "pear": {"rings": {}}}}`, ConfigVersionV1,
+			ValidationErrors{{
+				Path:  "ethtool.eth0.pear",
+				Value: `"pear"`,
+				Rule:  `unknown classifier, expected one of ["self" "peer" "parent"]`,
+			}}},
+		{`{"eth0": {"self": {"features": {"rx-gro": false}}, "parent": {"vf": {"spoofchk": false, "max-tx-rate": 100}}}}`,
+			ConfigVersionV1, nil},
+		{`{"eth0": {"self": {"vf": {"trust": true}}, "peer": {"features": {"rx-gro": false}}, "parent": {"vf": {}}}}`,
+			ConfigVersionV1, ValidationErrors{{
+				Path:  "ethtool.eth0.parent",
+				Value: `{"vf":{}}`,
+				Rule:  `must not be combined with "peer"`,
+			}, {
+				Path:  "ethtool.eth0.parent",
+				Value: `{"vf":{}}`,
+				Rule:  "must configure at least one setting",
+			}, {
+				Path:  "ethtool.eth0.parent.vf",
+				Value: "{}",
+				Rule:  "section must not be empty",
+			}, {
+				Path:  "ethtool.eth0.self.vf",
+				Value: `{"trust":true}`,
+				Rule:  `VF settings apply to the physical function, use "peer" or "parent" instead`,
+			}}},
+		{`{"eth0": {"self": {"channels": {}}}}`, ConfigVersionV1, ValidationErrors{{
+			Path:  "ethtool.eth0.self",
+			Value: `{"channels":{}}`,
+			Rule:  "must configure at least one setting",
+		}, {
+			Path:  "ethtool.eth0.self.channels",
+			Value: "{}",
+			Rule:  "section must not be empty",
+		}}},
+	}
+	for _, tc := range tcs {
+		var es EthtoolConfigs
+		if err := json.Unmarshal([]byte(tc.in), &es); err != nil {
+			t.Fatalf("Unmarshal(%s): expected to see no error but got %q", tc.in, err)
+		}
+		errs := es.Validate(tc.version)
+		if !reflect.DeepEqual(errs, tc.expected) {
+			t.Fatalf("Validate(%s, %q): expected %v but got %v", tc.in, tc.version, tc.expected, errs)
+		}
+	}
+}
+
+func TestValidateFeatures(t *testing.T) {
+	var es EthtoolConfigs
+	in := `{"eth0": {"self": {"features": {"tx-checksumming": false, "rx-checksum": true, "tx-checksuming": true}},
+		"peer": {"rx-gro": false, "rx-all": true}}}`
+	if err := json.Unmarshal([]byte(in), &es); err != nil {
+		t.Fatalf("Unmarshal(%s): expected to see no error but got %q", in, err)
+	}
+	backend := newFakeBackend()
+	errs, err := es.ValidateFeatures(backend, "eth0", SelfClassifier, "eth0")
+	if err != nil {
+		t.Fatalf("ValidateFeatures(self): expected to see no error but got %q", err)
+	}
+	expected := ValidationErrors{{
+		Path:  "ethtool.eth0.self.features.tx-checksuming",
+		Value: `"tx-checksuming"`,
+		Rule:  "interface eth0 does not offer this offloading attribute",
+	}}
+	if !reflect.DeepEqual(errs, expected) {
+		t.Fatalf("ValidateFeatures(self): expected %v but got %v", expected, errs)
+	}
+	errs, err = es.ValidateFeatures(backend, "eth0", PeerClassifier, "eth0")
+	if err != nil || len(errs) != 1 || errs[0].Path != "ethtool.eth0.peer.rx-gro" {
+		t.Fatalf("ValidateFeatures(peer): expected an error for rx-gro but got %v, err: %q", errs, err)
+	}
+	if _, err := es.ValidateFeatures(backend, "eth0", PeerClassifier, "veth1"); err == nil {
+		t.Fatalf("ValidateFeatures(peer): expected to see an error for a missing device")
+	}
+}
+
+func TestExecBackendFeatureNames(t *testing.T) {
+	ethtool = fakeEthtool
+	names, err := execBackend{}.FeatureNames("dummy0")
+	if err != nil {
+		t.Fatalf("FeatureNames(dummy0): expected to see no error but got %q", err)
+	}
+	unsupported := UnsupportedFeatures(names, map[string]bool{
+		"tx-checksumming":         true,
+		"tx-checksum-ip-generic":  true,
+		"rx-checksum":             true,
+		"generic-receive-offload": true,
+		"rx-gro":                  true,
+		"tx-checksuming":          true,
+	})
+	if !reflect.DeepEqual(unsupported, []string{"tx-checksuming"}) {
+		t.Fatalf("UnsupportedFeatures: expected only tx-checksuming to be unsupported but got %v", unsupported)
+	}
+	offload, ok := lookupOffload(dummy0OutputParsed, "rx-checksum")
+	if !ok || offload.IsActive() {
+		t.Fatalf("lookupOffload(rx-checksum): expected to find the inactive rx-checksumming but got %v", offload)
+	}
+}
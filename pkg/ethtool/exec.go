@@ -0,0 +1,703 @@
+package ethtool
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// execBackend runs the ethtool binary for every operation.
+type execBackend struct{}
+
+// ListFeatures implements Backend.
+func (execBackend) ListFeatures(iface string) (OffloadList, error) {
+	return List(iface)
+}
+
+// FeatureNames implements Backend. The ethtool binary shows long names instead of the kernel names, so the kernel
+// names are derived from the output of ethtool -k.
+func (execBackend) FeatureNames(iface string) ([]string, error) {
+	offloadList, err := List(iface)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range offloadList {
+		if lf, ok := findLegacyFeature(name); ok {
+			if len(lf.kernelNames) == 1 {
+				names = append(names, lf.kernelNames[0])
+			}
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SetFeatures implements Backend. All features are changed with a single call to ethtool -K.
+func (execBackend) SetFeatures(iface string, features map[string]bool) error {
+	if len(features) == 0 {
+		return nil
+	}
+	fields := make([]string, 0, len(features))
+	for field := range features {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	parameters := []string{"-K", iface}
+	for _, field := range fields {
+		parameters = append(parameters, field, status[features[field]])
+	}
+	_, err := ethtool(parameters...)
+	return err
+}
+
+// GetPrivFlags implements Backend. It parses the output of ethtool --show-priv-flags.
+func (execBackend) GetPrivFlags(iface string) (map[string]bool, error) {
+	out, err := ethtool("--show-priv-flags", iface)
+	if err != nil {
+		return nil, err
+	}
+	privFlags := map[string]bool{}
+	for _, section := range parseSections(out) {
+		for name, value := range section {
+			if v, ok := parseOnOff(value); ok {
+				privFlags[name] = v
+			}
+		}
+	}
+	return privFlags, nil
+}
+
+// SetPrivFlags implements Backend. All private flags are changed with a single call to ethtool --set-priv-flags.
+func (execBackend) SetPrivFlags(iface string, privFlags map[string]bool) error {
+	if len(privFlags) == 0 {
+		return nil
+	}
+	parameters := []string{"--set-priv-flags", iface}
+	for _, name := range privFlagNames(privFlags) {
+		parameters = append(parameters, name, status[privFlags[name]])
+	}
+	_, err := ethtool(parameters...)
+	return err
+}
+
+// GetRings implements Backend. It parses the output of ethtool -g.
+func (execBackend) GetRings(iface string) (*RingParameters, error) {
+	out, err := ethtool("-g", iface)
+	if err != nil {
+		return nil, err
+	}
+	sections := parseSections(out)
+	rings := &RingParameters{Current: map[string]uint32{}, Max: map[string]uint32{}}
+	for _, field := range ringFields {
+		if v, ok := parseUint32(sections[sectionMaximums][field.label]); ok {
+			rings.Max[field.name] = v
+		}
+		if v, ok := parseUint32(sections[sectionCurrent][field.label]); ok {
+			rings.Current[field.name] = v
+		}
+	}
+	return rings, nil
+}
+
+// SetRings implements Backend.
+func (execBackend) SetRings(iface string, rings map[string]uint32) error {
+	if len(rings) == 0 {
+		return nil
+	}
+	parameters := []string{"-G", iface}
+	for _, field := range ringFields {
+		if v, ok := rings[field.name]; ok {
+			parameters = append(parameters, field.name, strconv.FormatUint(uint64(v), 10))
+		}
+	}
+	_, err := ethtool(parameters...)
+	return err
+}
+
+// GetChannels implements Backend. It parses the output of ethtool -l.
+func (execBackend) GetChannels(iface string) (*ChannelParameters, error) {
+	out, err := ethtool("-l", iface)
+	if err != nil {
+		return nil, err
+	}
+	sections := parseSections(out)
+	channels := &ChannelParameters{Current: map[string]uint32{}, Max: map[string]uint32{}}
+	for _, field := range channelFields {
+		if v, ok := parseUint32(sections[sectionMaximums][field.label]); ok {
+			channels.Max[field.name] = v
+		}
+		if v, ok := parseUint32(sections[sectionCurrent][field.label]); ok {
+			channels.Current[field.name] = v
+		}
+	}
+	return channels, nil
+}
+
+// SetChannels implements Backend.
+func (execBackend) SetChannels(iface string, channels map[string]uint32) error {
+	if len(channels) == 0 {
+		return nil
+	}
+	parameters := []string{"-L", iface}
+	for _, field := range channelFields {
+		if v, ok := channels[field.name]; ok {
+			parameters = append(parameters, field.name, strconv.FormatUint(uint64(v), 10))
+		}
+	}
+	_, err := ethtool(parameters...)
+	return err
+}
+
+// GetCoalesce implements Backend. It parses the output of ethtool -c.
+func (execBackend) GetCoalesce(iface string) (*Coalesce, error) {
+	out, err := ethtool("-c", iface)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, section := range parseSections(out) {
+		for key, value := range section {
+			values[key] = value
+		}
+	}
+	coalesce := &Coalesce{}
+	numbers := coalesce.numbers()
+	for _, field := range coalesceFields {
+		if v, ok := parseUint32(values[field.name]); ok {
+			*numbers[field.name] = &v
+		}
+	}
+	flags := coalesce.flags()
+	for _, field := range coalesceFlags {
+		if v, ok := parseOnOff(splitRXTX(values[field.label], field.tx)); ok {
+			*flags[field.name] = &v
+		}
+	}
+	return coalesce, nil
+}
+
+// SetCoalesce implements Backend.
+func (execBackend) SetCoalesce(iface string, coalesce *Coalesce) error {
+	if coalesce.IsEmpty() {
+		return nil
+	}
+	parameters := []string{"-C", iface}
+	numbers := coalesce.numbers()
+	for _, field := range coalesceFields {
+		if v := *numbers[field.name]; v != nil {
+			parameters = append(parameters, field.name, strconv.FormatUint(uint64(*v), 10))
+		}
+	}
+	flags := coalesce.flags()
+	for _, field := range coalesceFlags {
+		if v := *flags[field.name]; v != nil {
+			parameters = append(parameters, field.name, status[*v])
+		}
+	}
+	_, err := ethtool(parameters...)
+	return err
+}
+
+// GetPause implements Backend. It parses the output of ethtool -a.
+func (execBackend) GetPause(iface string) (*Pause, error) {
+	out, err := ethtool("-a", iface)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, section := range parseSections(out) {
+		for key, value := range section {
+			values[key] = value
+		}
+	}
+	pause := &Pause{}
+	fields := pause.fields()
+	for _, field := range pauseFields {
+		if v, ok := parseOnOff(values[field.label]); ok {
+			*fields[field.name] = &v
+		}
+	}
+	return pause, nil
+}
+
+// SetPause implements Backend.
+func (execBackend) SetPause(iface string, pause *Pause) error {
+	if pause.IsEmpty() {
+		return nil
+	}
+	parameters := []string{"-A", iface}
+	fields := pause.fields()
+	for _, field := range pauseFields {
+		if v := *fields[field.name]; v != nil {
+			parameters = append(parameters, field.name, status[*v])
+		}
+	}
+	_, err := ethtool(parameters...)
+	return err
+}
+
+// PFCEnabled implements Backend. The ethtool binary cannot read DCB settings, so it reads them through rtnetlink.
+func (execBackend) PFCEnabled(iface string) (bool, error) {
+	return pfcEnabled(iface)
+}
+
+// GetLinkSettings implements Backend. It parses the output of ethtool <iface>.
+func (execBackend) GetLinkSettings(iface string) (*LinkParameters, error) {
+	out, err := ethtool(iface)
+	if err != nil {
+		return nil, err
+	}
+	values := parseSettings(out)
+	params := &LinkParameters{
+		Current:   Link{Advertise: parseLinkModes(values["Advertised link modes"])},
+		Supported: parseLinkModes(values["Supported link modes"]),
+	}
+	if values["Supports auto-negotiation"] == "Yes" {
+		params.Supported = append(params.Supported, linkModeAutoneg)
+	}
+	if v, ok := parseOnOff(values["Auto-negotiation"]); ok {
+		params.Current.Autoneg = &v
+	}
+	if v, ok := parseUint32(strings.TrimSuffix(values["Speed"], "Mb/s")); ok {
+		params.Current.Speed = &v
+	}
+	if duplex := strings.ToLower(values["Duplex"]); duplex == DuplexHalf || duplex == DuplexFull {
+		params.Current.Duplex = &duplex
+	}
+	// ethtool shows "on (forced)" and "off (forced)" for a forced MDI-X and appends "(auto)" to the current state
+	// otherwise.
+	var mdix string
+	switch value := values["MDI-X"]; {
+	case strings.HasSuffix(value, "(auto)"):
+		mdix = MDIXAuto
+	case value == "on (forced)":
+		mdix = MDIXOn
+	case value == "off (forced)":
+		mdix = MDIXOff
+	}
+	if mdix != "" {
+		params.Current.MDIX = &mdix
+	}
+	return params, nil
+}
+
+// SetLinkSettings implements Backend. Advertised link modes are changed with an on/off pair for every speed and
+// duplex link mode of the device, so that other capabilities such as "Pause" are not changed.
+func (b execBackend) SetLinkSettings(iface string, link *Link) error {
+	if link.IsEmpty() {
+		return nil
+	}
+	parameters := []string{"-s", iface}
+	if link.Speed != nil {
+		parameters = append(parameters, LinkSpeed, strconv.FormatUint(uint64(*link.Speed), 10))
+	}
+	if link.Duplex != nil {
+		parameters = append(parameters, LinkDuplex, *link.Duplex)
+	}
+	if link.Autoneg != nil {
+		parameters = append(parameters, LinkAutoneg, status[*link.Autoneg])
+	}
+	if len(link.Advertise) > 0 {
+		params, err := b.GetLinkSettings(iface)
+		if err != nil {
+			return err
+		}
+		parameters = append(parameters, LinkAdvertise)
+		for _, mode := range speedModes(params.Supported) {
+			parameters = append(parameters, mode, status[slices.Contains(link.Advertise, mode)])
+		}
+	}
+	if link.MDIX != nil {
+		parameters = append(parameters, LinkMDIX, *link.MDIX)
+	}
+	_, err := ethtool(parameters...)
+	return err
+}
+
+// GetRSS implements Backend. It parses the output of ethtool -x.
+func (execBackend) GetRSS(iface string, context uint32) (*RSSParameters, error) {
+	parameters := []string{"-x", iface}
+	if context != 0 {
+		parameters = append(parameters, "context", strconv.FormatUint(uint64(context), 10))
+	}
+	out, err := ethtool(parameters...)
+	if err != nil {
+		return nil, err
+	}
+	params := &RSSParameters{}
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := rxRingsRegexp.FindStringSubmatch(line); match != nil {
+			params.RXRings, _ = parseUint32(match[1])
+			section = sectionIndirectionTable
+			continue
+		}
+		if strings.HasSuffix(line, ":") {
+			section = strings.TrimSuffix(line, ":")
+			continue
+		}
+		switch section {
+		case sectionIndirectionTable:
+			_, rings, found := strings.Cut(line, ":")
+			if !found {
+				continue
+			}
+			for _, field := range strings.Fields(rings) {
+				if ring, ok := parseUint32(field); ok {
+					params.Current.Table = append(params.Current.Table, ring)
+				}
+			}
+		case sectionHashKey:
+			if _, err := parseHKey(line); err == nil {
+				hkey := line
+				params.Current.HKey = &hkey
+			}
+		case sectionHashFunction:
+			name, value, found := strings.Cut(line, ":")
+			if !found {
+				continue
+			}
+			hfunc := strings.TrimSpace(name)
+			params.HFuncs = append(params.HFuncs, hfunc)
+			if strings.TrimSpace(value) == status[true] {
+				params.Current.HFunc = &hfunc
+			}
+		}
+	}
+	return params, nil
+}
+
+// SetRSS implements Backend.
+func (execBackend) SetRSS(iface string, context uint32, rss *RSSContext) error {
+	parameters, err := rssParameters(iface, strconv.FormatUint(uint64(context), 10), rss)
+	if err != nil {
+		return err
+	}
+	_, err = ethtool(parameters...)
+	return err
+}
+
+// ResetRSSTable implements Backend.
+func (execBackend) ResetRSSTable(iface string) error {
+	_, err := ethtool("-X", iface, "default")
+	return err
+}
+
+// CreateRSSContext implements Backend. It parses the ID of the new context from the output of ethtool -X.
+func (execBackend) CreateRSSContext(iface string, rss *RSSContext) (uint32, error) {
+	parameters, err := rssParameters(iface, "new", rss)
+	if err != nil {
+		return 0, err
+	}
+	out, err := ethtool(parameters...)
+	if err != nil {
+		return 0, err
+	}
+	match := newRSSContextRegexp.FindSubmatch(out)
+	if match == nil {
+		return 0, fmt.Errorf("could not find the ID of the new RSS context in output %q", out)
+	}
+	id, _ := parseUint32(string(match[1]))
+	return id, nil
+}
+
+// DeleteRSSContext implements Backend.
+func (execBackend) DeleteRSSContext(iface string, context uint32) error {
+	_, err := ethtool("-X", iface, "context", strconv.FormatUint(uint64(context), 10), "delete")
+	if notFound(err) {
+		return nil
+	}
+	return err
+}
+
+// GetFlowRule implements Backend. It parses the output of ethtool -n rule, which shows masks inverted, i.e. a 1
+// ignores the bit. Like netlinkBackend.GetFlowRule, it treats EINVAL as an empty location.
+func (execBackend) GetFlowRule(iface string, loc uint32) (*FlowRule, error) {
+	out, err := ethtool("-n", iface, "rule", strconv.FormatUint(uint64(loc), 10))
+	if notFound(err) || failedWith(err, "Invalid argument") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	values := parseSections(out)[""]
+	rule := &FlowRule{Loc: &loc}
+	for name, ruleType := range flowRuleTypes {
+		if values["Rule Type"] == ruleType {
+			rule.FlowType = name
+		}
+	}
+	if rule.FlowType == "" {
+		return nil, fmt.Errorf("unsupported rule type %q of flow rule %d", values["Rule Type"], loc)
+	}
+	for key, ip := range map[string]**string{"Src IP addr": &rule.SrcIP, "Dest IP addr": &rule.DstIP} {
+		value, mask, _ := strings.Cut(values[key], " mask: ")
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			continue
+		}
+		inverted, err := netip.ParseAddr(mask)
+		if err != nil {
+			continue
+		}
+		if prefix, ok := prefixFromMask(addr.AsSlice(), invertMask(inverted.AsSlice())); ok {
+			s := prefix.String()
+			*ip = &s
+		}
+	}
+	for key, port := range map[string]**uint16{"Src port": &rule.SrcPort, "Dest port": &rule.DstPort} {
+		value, mask, _ := strings.Cut(values[key], " mask: ")
+		if mask != "0x0" {
+			continue
+		}
+		if v, err := strconv.ParseUint(value, 10, 16); err == nil {
+			p := uint16(v)
+			*port = &p
+		}
+	}
+	action := int64(FlowRuleActionDrop)
+	if queue, found := strings.CutPrefix(values["Action"], "Direct to queue "); found {
+		if action, err = strconv.ParseInt(queue, 10, 64); err != nil {
+			return nil, fmt.Errorf("could not parse action %q of flow rule %d", values["Action"], loc)
+		}
+	} else if values["Action"] != "Drop" {
+		return nil, fmt.Errorf("unsupported action %q of flow rule %d", values["Action"], loc)
+	}
+	rule.Action = &action
+	return rule, nil
+}
+
+// InsertFlowRule implements Backend. It parses the location of the new rule from the output of ethtool -N.
+func (execBackend) InsertFlowRule(iface string, rule *FlowRule) (uint32, error) {
+	parameters := []string{"-N", iface, FlowRuleFlowType, rule.FlowType}
+	for i, ip := range []*string{rule.SrcIP, rule.DstIP} {
+		if ip == nil {
+			continue
+		}
+		prefix, err := parsePrefix(*ip)
+		if err != nil {
+			return 0, err
+		}
+		parameters = append(parameters, []string{FlowRuleSrcIP, FlowRuleDstIP}[i], prefix.Masked().Addr().String())
+		if !prefix.IsSingleIP() {
+			inverted, _ := netip.AddrFromSlice(invertMask(prefixMask(prefix)))
+			parameters = append(parameters, "m", inverted.String())
+		}
+	}
+	for i, port := range []*uint16{rule.SrcPort, rule.DstPort} {
+		if port != nil {
+			parameters = append(parameters, []string{FlowRuleSrcPort, FlowRuleDstPort}[i],
+				strconv.FormatUint(uint64(*port), 10))
+		}
+	}
+	if rule.Action != nil {
+		parameters = append(parameters, FlowRuleAction, strconv.FormatInt(*rule.Action, 10))
+	}
+	if rule.Loc != nil {
+		parameters = append(parameters, FlowRuleLoc, strconv.FormatUint(uint64(*rule.Loc), 10))
+	}
+	out, err := ethtool(parameters...)
+	if err != nil {
+		return 0, err
+	}
+	match := addedRuleRegexp.FindSubmatch(out)
+	if match == nil {
+		return 0, fmt.Errorf("could not find the location of the new flow rule in output %q", out)
+	}
+	loc, _ := parseUint32(string(match[1]))
+	return loc, nil
+}
+
+// DeleteFlowRule implements Backend.
+func (execBackend) DeleteFlowRule(iface string, loc uint32) error {
+	_, err := ethtool("-N", iface, "delete", strconv.FormatUint(uint64(loc), 10))
+	if notFound(err) {
+		return nil
+	}
+	return err
+}
+
+// notFound returns true if err is the error of an ethtool command that failed because the object does not exist.
+// ethtool prints the error of the kernel, ENOENT, to stderr, e.g. "rmgr: Cannot delete RX class rule: No such file or
+// directory".
+func notFound(err error) bool {
+	return failedWith(err, "No such file or directory")
+}
+
+// failedWith returns true if err is the error of an ethtool command that printed message to stderr.
+func failedWith(err error, message string) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && bytes.Contains(exitErr.Stderr, []byte(message))
+}
+
+// invertMask returns mask with every bit flipped, ethtool shows and accepts masks that way.
+func invertMask(mask []byte) []byte {
+	inverted := make([]byte, len(mask))
+	for i := range mask {
+		inverted[i] = ^mask[i]
+	}
+	return inverted
+}
+
+// rssParameters returns the parameters of ethtool -X that change the RSS settings in rss of context context of iface.
+// ethtool cannot set an explicit indirection table.
+func rssParameters(iface string, context string, rss *RSSContext) ([]string, error) {
+	if len(rss.Table) > 0 {
+		return nil, fmt.Errorf("ethtool -X cannot set an explicit indirection table, use backend %q instead",
+			BackendNetlink)
+	}
+	parameters := []string{"-X", iface}
+	if context != "0" {
+		parameters = append(parameters, "context", context)
+	}
+	if rss.Equal != nil {
+		parameters = append(parameters, RSSEqual, strconv.FormatUint(uint64(*rss.Equal), 10))
+	}
+	if len(rss.Weight) > 0 {
+		parameters = append(parameters, RSSWeight)
+		for _, weight := range rss.Weight {
+			parameters = append(parameters, strconv.FormatUint(uint64(weight), 10))
+		}
+	}
+	if rss.HKey != nil {
+		parameters = append(parameters, RSSHKey, *rss.HKey)
+	}
+	if rss.HFunc != nil {
+		parameters = append(parameters, RSSHFunc, *rss.HFunc)
+	}
+	return parameters, nil
+}
+
+const (
+	sectionMaximums         = "Pre-set maximums"
+	sectionCurrent          = "Current hardware settings"
+	sectionIndirectionTable = "RX flow hash indirection table"
+	sectionHashKey          = "RSS hash key"
+	sectionHashFunction     = "RSS hash function"
+)
+
+var (
+	// rxRingsRegexp matches the first line of ethtool -x, e.g. "RX flow hash indirection table for ens3 with 8 RX
+	// ring(s):".
+	rxRingsRegexp = regexp.MustCompile(`^RX flow hash indirection table for .* with (\d+) RX ring`)
+	// newRSSContextRegexp matches the output of ethtool -X context new, e.g. "New RSS context is 1".
+	newRSSContextRegexp = regexp.MustCompile(`New RSS context is (\d+)`)
+	// addedRuleRegexp matches the output of ethtool -N, e.g. "Added rule with ID 1023".
+	addedRuleRegexp = regexp.MustCompile(`Added rule with ID (\d+)`)
+
+	// flowRuleTypes maps flow types to the rule types that ethtool -n shows.
+	flowRuleTypes = map[string]string{
+		"tcp4":  "TCP over IPv4",
+		"udp4":  "UDP over IPv4",
+		"sctp4": "SCTP over IPv4",
+		"ip4":   "Raw IPv4",
+		"tcp6":  "TCP over IPv6",
+		"udp6":  "UDP over IPv6",
+		"sctp6": "SCTP over IPv6",
+		"ip6":   "Raw IPv6",
+	}
+)
+
+// parseSections parses the "key: value" output of the ethtool show commands, e.g. ethtool -g. Lines that end with
+// a colon start a new section. Keys before the first section belong to section "".
+func parseSections(out []byte) map[string]map[string]string {
+	sections := map[string]map[string]string{"": {}}
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasSuffix(line, ":") {
+			section = strings.TrimSuffix(line, ":")
+			sections[section] = map[string]string{}
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		sections[section][strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return sections
+}
+
+// parseSettings parses the "key: value" output of ethtool <iface>. Lines without a colon continue the value of the
+// previous key, e.g. the list of supported link modes.
+func parseSettings(out []byte) map[string]string {
+	values := map[string]string{}
+	key := ""
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasSuffix(line, ":") {
+			continue
+		}
+		k, value, found := strings.Cut(line, ":")
+		if !found {
+			if key != "" {
+				values[key] += " " + line
+			}
+			continue
+		}
+		key = strings.TrimSpace(k)
+		values[key] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+// parseLinkModes parses a list of link modes of ethtool <iface>, e.g. "10baseT/Half 10baseT/Full". Values such as
+// "Not reported" are reported as no link modes.
+func parseLinkModes(s string) []string {
+	if s == "Not reported" {
+		return nil
+	}
+	return strings.Fields(s)
+}
+
+// parseUint32 parses a numeric value of the ethtool output. Values such as "n/a" are reported as not ok.
+func parseUint32(s string) (uint32, bool) {
+	v, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(v), true
+}
+
+// parseOnOff parses a flag of the ethtool output. Values such as "n/a" are reported as not ok.
+func parseOnOff(s string) (bool, bool) {
+	switch s {
+	case status[true]:
+		return true, true
+	case status[false]:
+		return false, true
+	}
+	return false, false
+}
+
+// splitRXTX returns one of the values of a line that shows an rx and a tx flag side by side. For line
+// "Adaptive RX: off  TX: on", parseSections returns value "off  TX: on".
+func splitRXTX(value string, tx bool) string {
+	rx, txValue, found := strings.Cut(value, "TX:")
+	if tx {
+		if !found {
+			return ""
+		}
+		return strings.TrimSpace(txValue)
+	}
+	return strings.TrimSpace(rx)
+}
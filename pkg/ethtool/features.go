@@ -0,0 +1,105 @@
+package ethtool
+
+import (
+	"sort"
+)
+
+// legacyFeature maps one of the offloading attributes that the ethtool binary shows under a long name, e.g.
+// "tx-checksumming", to the kernel features (the ETH_SS_FEATURES string set) that it stands for.
+type legacyFeature struct {
+	name        string
+	kernelNames []string
+}
+
+// legacyFeatures mirrors the off_flag_def table of the ethtool binary, in the same order.
+var legacyFeatures = []legacyFeature{
+	{"rx-checksumming", []string{"rx-checksum"}},
+	{"tx-checksumming", []string{"tx-checksum-ipv4", "tx-checksum-ip-generic", "tx-checksum-ipv6",
+		"tx-checksum-fcoe-crc", "tx-checksum-sctp"}},
+	{"scatter-gather", []string{"tx-scatter-gather", "tx-scatter-gather-fraglist"}},
+	{"tcp-segmentation-offload", []string{"tx-tcp-segmentation", "tx-tcp-ecn-segmentation",
+		"tx-tcp-mangleid-segmentation", "tx-tcp6-segmentation"}},
+	{"udp-fragmentation-offload", []string{"tx-udp-fragmentation"}},
+	{"generic-segmentation-offload", []string{"tx-generic-segmentation"}},
+	{"generic-receive-offload", []string{"rx-gro"}},
+	{"large-receive-offload", []string{"rx-lro"}},
+	{"rx-vlan-offload", []string{"rx-vlan-hw-parse"}},
+	{"tx-vlan-offload", []string{"tx-vlan-hw-insert"}},
+	{"ntuple-filters", []string{"rx-ntuple-filter"}},
+	{"receive-hashing", []string{"rx-hashing"}},
+}
+
+// findLegacyFeature returns the legacy feature with the provided long name.
+func findLegacyFeature(name string) (legacyFeature, bool) {
+	for _, lf := range legacyFeatures {
+		if lf.name == name {
+			return lf, true
+		}
+	}
+	return legacyFeature{}, false
+}
+
+// UnsupportedFeatures returns the offloading attributes of features that interface iface does not offer, ordered
+// alphabetically. names is the ETH_SS_FEATURES string set of iface. Both long names such as "tx-checksumming" and
+// kernel names such as "tx-checksum-ip-generic" are accepted. A long name is supported if iface offers at least one of
+// the kernel features that it stands for.
+func UnsupportedFeatures(names []string, features map[string]bool) []string {
+	known := map[string]bool{}
+	for _, name := range names {
+		known[name] = true
+	}
+	var unsupported []string
+	for feature := range features {
+		if known[feature] {
+			continue
+		}
+		supported := false
+		if lf, ok := findLegacyFeature(feature); ok {
+			for _, kernelName := range lf.kernelNames {
+				supported = supported || known[kernelName]
+			}
+		}
+		if !supported {
+			unsupported = append(unsupported, feature)
+		}
+	}
+	sort.Strings(unsupported)
+	return unsupported
+}
+
+// snapshotFeatureNames returns the names under which Snapshot records the state of offloading attribute feature. A
+// long name such as "tcp-segmentation-offload" is active if any of its kernel features is active, so the kernel
+// features that can change are recorded one by one instead, and each of them is restored to its own state.
+func snapshotFeatureNames(offloadList OffloadList, feature string) []string {
+	lf, ok := findLegacyFeature(feature)
+	if !ok || len(lf.kernelNames) == 1 {
+		return []string{feature}
+	}
+	var names []string
+	for _, kernelName := range lf.kernelNames {
+		if offload, ok := offloadList[kernelName]; ok && !offload.IsFixed() {
+			names = append(names, kernelName)
+		}
+	}
+	// The device shows a long name whose other kernel features it does not offer in place of the kernel feature.
+	if len(names) == 0 {
+		return []string{feature}
+	}
+	return names
+}
+
+// lookupOffload returns the offloading attribute with the provided name from offloadList. Like the ethtool binary,
+// offloadList shows kernel features that a long name stands for on its own under the long name only, e.g.
+// "rx-checksum" as "rx-checksumming". lookupOffload resolves such kernel names, too.
+func lookupOffload(offloadList OffloadList, name string) (Offload, bool) {
+	if offload, ok := offloadList[name]; ok {
+		return offload, true
+	}
+	for _, lf := range legacyFeatures {
+		if len(lf.kernelNames) == 1 && lf.kernelNames[0] == name {
+			offload, ok := offloadList[lf.name]
+			return offload, ok
+		}
+	}
+	return Offload{}, false
+}
@@ -0,0 +1,398 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"slices"
+)
+
+const (
+	FlowRuleFlowType = "flow-type"
+	FlowRuleSrcIP    = "src-ip"
+	FlowRuleDstIP    = "dst-ip"
+	FlowRuleSrcPort  = "src-port"
+	FlowRuleDstPort  = "dst-port"
+	FlowRuleAction   = "action"
+	FlowRuleLoc      = "loc"
+
+	// FlowRuleActionDrop is the action that drops matching packets instead of steering them to an RX ring.
+	FlowRuleActionDrop = -1
+
+	// ntupleFeature is the offloading attribute that enables flow rules.
+	ntupleFeature       = "ntuple-filters"
+	ntupleKernelFeature = "rx-ntuple-filter"
+)
+
+// flowTypes maps the flow types of ethtool -N to their ethtool_rx_flow_spec flow_type values and tells whether they
+// match IPv6 and ports.
+var flowTypes = map[string]struct {
+	value uint32
+	ipv6  bool
+	ports bool
+}{
+	"tcp4":  {ethtoolTCPV4Flow, false, true},
+	"udp4":  {ethtoolUDPV4Flow, false, true},
+	"sctp4": {ethtoolSCTPV4Flow, false, true},
+	"ip4":   {ethtoolIPV4UserFlow, false, false},
+	"tcp6":  {ethtoolTCPV6Flow, true, true},
+	"udp6":  {ethtoolUDPV6Flow, true, true},
+	"sctp6": {ethtoolSCTPV6Flow, true, true},
+	"ip6":   {ethtoolIPV6UserFlow, true, false},
+}
+
+// FlowRule is a receive flow classification rule, see ethtool -N. Packets of FlowType that match every configured
+// field are steered to the RX ring Action.
+type FlowRule struct {
+	// FlowType is one of "tcp4", "udp4", "sctp4", "ip4", "tcp6", "udp6", "sctp6" and "ip6".
+	FlowType string `json:"flow-type"`
+	// SrcIP and DstIP are an address or a prefix, e.g. "192.0.2.1" or "192.0.2.0/24", of the family of FlowType.
+	SrcIP *string `json:"src-ip,omitempty"`
+	DstIP *string `json:"dst-ip,omitempty"`
+	// SrcPort and DstPort are only supported by TCP, UDP and SCTP flow types.
+	SrcPort *uint16 `json:"src-port,omitempty"`
+	DstPort *uint16 `json:"dst-port,omitempty"`
+	// Action is the RX ring that receives matching packets, or FlowRuleActionDrop.
+	Action *int64 `json:"action,omitempty"`
+	// Loc is the location of the rule in the rule table of the device, which also decides between overlapping rules.
+	// If it is not set, the driver selects a location if it supports that, and else the last free location is used.
+	Loc *uint32 `json:"loc,omitempty"`
+}
+
+func (r FlowRule) String() string {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Copy returns a deep copy of r.
+func (r *FlowRule) Copy() *FlowRule {
+	copied := &FlowRule{FlowType: r.FlowType}
+	if r.SrcIP != nil {
+		v := *r.SrcIP
+		copied.SrcIP = &v
+	}
+	if r.DstIP != nil {
+		v := *r.DstIP
+		copied.DstIP = &v
+	}
+	if r.SrcPort != nil {
+		v := *r.SrcPort
+		copied.SrcPort = &v
+	}
+	if r.DstPort != nil {
+		v := *r.DstPort
+		copied.DstPort = &v
+	}
+	if r.Action != nil {
+		v := *r.Action
+		copied.Action = &v
+	}
+	if r.Loc != nil {
+		v := *r.Loc
+		copied.Loc = &v
+	}
+	return copied
+}
+
+// validateSchema checks the values of r that do not depend on the device and returns every problem that it finds.
+// path is the JSON path of r.
+func (r *FlowRule) validateSchema(path string) ValidationErrors {
+	var errs ValidationErrors
+	flowType, ok := flowTypes[r.FlowType]
+	if !ok {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, FlowRuleFlowType),
+			Value: fmt.Sprintf("%q", r.FlowType),
+			Rule:  fmt.Sprintf("must be one of %q", flowTypeNames()),
+		})
+	}
+	for name, ip := range map[string]*string{FlowRuleSrcIP: r.SrcIP, FlowRuleDstIP: r.DstIP} {
+		if ip == nil {
+			continue
+		}
+		prefix, err := parsePrefix(*ip)
+		switch {
+		case err != nil:
+			errs = append(errs, ValidationError{
+				Path:  joinPath(path, name),
+				Value: fmt.Sprintf("%q", *ip),
+				Rule:  fmt.Sprintf("must be an IP address or a prefix, e.g. %q", "192.0.2.0/24"),
+			})
+		case ok && prefix.Addr().Is6() != flowType.ipv6:
+			errs = append(errs, ValidationError{
+				Path:  joinPath(path, name),
+				Value: fmt.Sprintf("%q", *ip),
+				Rule:  fmt.Sprintf("address family does not match flow type %q", r.FlowType),
+			})
+		}
+	}
+	for name, port := range map[string]*uint16{FlowRuleSrcPort: r.SrcPort, FlowRuleDstPort: r.DstPort} {
+		if port != nil && ok && !flowType.ports {
+			errs = append(errs, ValidationError{
+				Path:  joinPath(path, name),
+				Value: fmt.Sprint(*port),
+				Rule:  fmt.Sprintf("flow type %q does not have ports", r.FlowType),
+			})
+		}
+	}
+	if r.Action == nil {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, FlowRuleAction),
+			Value: "null",
+			Rule:  fmt.Sprintf("must be an RX ring or %d to drop matching packets", FlowRuleActionDrop),
+		})
+	} else if *r.Action < FlowRuleActionDrop {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, FlowRuleAction),
+			Value: fmt.Sprint(*r.Action),
+			Rule:  fmt.Sprintf("must be an RX ring or %d to drop matching packets", FlowRuleActionDrop),
+		})
+	}
+	return errs
+}
+
+// canonical returns r with addresses in their shortest notation, so that rules can be compared.
+func (r *FlowRule) canonical() *FlowRule {
+	c := r.Copy()
+	for _, ip := range []**string{&c.SrcIP, &c.DstIP} {
+		if *ip == nil {
+			continue
+		}
+		prefix, err := parsePrefix(**ip)
+		if err != nil {
+			continue
+		}
+		s := prefix.Masked().String()
+		if prefix.IsSingleIP() {
+			s = prefix.Addr().String()
+		}
+		*ip = &s
+	}
+	return c
+}
+
+// matches returns true if current, a rule that the device reports, is r. The location is only compared if r sets it.
+func (r *FlowRule) matches(current *FlowRule) bool {
+	expected, actual := r.canonical(), current.canonical()
+	if expected.Loc == nil {
+		actual.Loc = nil
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+// validateFlowRules checks the list of flow rules at path and returns every problem that it finds.
+func validateFlowRules(path string, rules []FlowRule) ValidationErrors {
+	if len(rules) == 0 {
+		return ValidationErrors{{Path: path, Value: "[]", Rule: "must not be empty"}}
+	}
+	var errs ValidationErrors
+	locs := map[uint32]bool{}
+	for i := range rules {
+		rulePath := fmt.Sprintf("%s[%d]", path, i)
+		errs = append(errs, rules[i].validateSchema(rulePath)...)
+		if loc := rules[i].Loc; loc != nil {
+			if locs[*loc] {
+				errs = append(errs, ValidationError{
+					Path:  joinPath(rulePath, FlowRuleLoc),
+					Value: fmt.Sprint(*loc),
+					Rule:  "must be unique",
+				})
+			}
+			locs[*loc] = true
+		}
+	}
+	return errs
+}
+
+// flowTypeNames returns the supported flow types in alphabetical order.
+func flowTypeNames() []string {
+	names := make([]string, 0, len(flowTypes))
+	for name := range flowTypes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// parsePrefix parses an address or a prefix. An address is returned as a prefix of its full length.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	return netip.ParsePrefix(s)
+}
+
+// prefixMask returns the mask of prefix with a 1 for every bit that is compared.
+func prefixMask(prefix netip.Prefix) []byte {
+	mask := make([]byte, prefix.Addr().BitLen()/8)
+	for i := 0; i < prefix.Bits(); i++ {
+		mask[i/8] |= 0x80 >> (i % 8)
+	}
+	return mask
+}
+
+// prefixFromMask returns the prefix of addr and mask. ok is false if the mask is empty, i.e. if every address
+// matches. Masks that are not contiguous are truncated to their leading ones.
+func prefixFromMask(addr, mask []byte) (prefix netip.Prefix, ok bool) {
+	ip, valid := netip.AddrFromSlice(addr)
+	if !valid {
+		return netip.Prefix{}, false
+	}
+	bits := 0
+	for bits < len(mask)*8 && mask[bits/8]&(0x80>>(bits%8)) != 0 {
+		bits++
+	}
+	if bits == 0 {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(ip, bits).Masked(), true
+}
+
+// checkNtuple returns an error if offloadList, the offloading attributes of interface iface, does not enable flow
+// rules. enabling is true if the settings of iface enable them before the rules are installed.
+func checkNtuple(iface string, offloadList OffloadList, enabling bool) error {
+	offload, ok := lookupOffload(offloadList, ntupleFeature)
+	if !ok || (!offload.IsActive() && offload.IsFixed()) {
+		return fmt.Errorf("interface %s does not support flow rules, offloading attribute %q is off [fixed]", iface,
+			ntupleFeature)
+	}
+	if !offload.IsActive() && !enabling {
+		return fmt.Errorf("interface %s does not accept flow rules, offloading attribute %q is off, enable it in "+
+			"section %q", iface, ntupleFeature, featuresKey)
+	}
+	return nil
+}
+
+// ApplyFlowRules installs the flow rules of interface iface and returns the installed rules in the order of rules,
+// each with its location. installed are the rules that a previous call returned. Installed rules are kept as long as
+// they match rules, starting with the first one. Every other installed rule is deleted, see DeleteFlowRules, and the
+// remaining rules are installed. A rule with a location fails if another rule, e.g. of another pod on a shared
+// device, uses the location. If installing a rule fails, the rules that are installed at that point are returned
+// together with the error. Offloading attribute "ntuple-filters" must be on.
+func ApplyFlowRules(b Backend, iface string, rules, installed []FlowRule) ([]FlowRule, error) {
+	if len(rules) == 0 && len(installed) == 0 {
+		return nil, nil
+	}
+	if len(rules) > 0 {
+		offloadList, err := b.ListFeatures(iface)
+		if err != nil {
+			return installed, fmt.Errorf("could not read offloading attributes of interface %s, err: %q", iface, err)
+		}
+		if err := checkNtuple(iface, offloadList, false); err != nil {
+			return installed, err
+		}
+	}
+	kept := 0
+	for kept < len(rules) && kept < len(installed) {
+		current, err := b.GetFlowRule(iface, *installed[kept].Loc)
+		if err != nil {
+			return installed, err
+		}
+		if current == nil || !installed[kept].matches(current) || !rules[kept].matches(current) {
+			break
+		}
+		kept++
+	}
+	if err := DeleteFlowRules(b, iface, installed[kept:]); err != nil {
+		return installed, err
+	}
+	result := slices.Clone(installed[:kept])
+	for i := kept; i < len(rules); i++ {
+		if rules[i].Loc != nil {
+			current, err := b.GetFlowRule(iface, *rules[i].Loc)
+			if err != nil {
+				return result, err
+			}
+			if current != nil {
+				return result, fmt.Errorf("interface %s, flow rule %d: location %d is in use by flow rule %s", iface,
+					i, *rules[i].Loc, current.canonical())
+			}
+		}
+		loc, err := b.InsertFlowRule(iface, &rules[i])
+		if err != nil {
+			return result, fmt.Errorf("interface %s, flow rule %d: %w", iface, i, err)
+		}
+		rule := rules[i].Copy()
+		rule.Loc = &loc
+		result = append(result, *rule)
+	}
+	return result, nil
+}
+
+// DeleteFlowRules deletes the installed flow rules of interface iface, see ApplyFlowRules, in reverse order. A rule
+// is only deleted if the rule at its location still matches it: if it was deleted outside of the plugin, another
+// rule may use the location now.
+func DeleteFlowRules(b Backend, iface string, installed []FlowRule) error {
+	for i := len(installed) - 1; i >= 0; i-- {
+		current, err := b.GetFlowRule(iface, *installed[i].Loc)
+		if err != nil {
+			return err
+		}
+		if current == nil || !installed[i].matches(current) {
+			continue
+		}
+		if err := b.DeleteFlowRule(iface, *installed[i].Loc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompareFlowRules reads the installed flow rules of interface iface and returns a description of every rule of rules
+// that differs, see ApplyFlowRules.
+func CompareFlowRules(b Backend, iface string, rules, installed []FlowRule) ([]string, error) {
+	var mismatches []string
+	for i := range rules {
+		var current *FlowRule
+		if i < len(installed) {
+			var err error
+			current, err = b.GetFlowRule(iface, *installed[i].Loc)
+			if err != nil {
+				return nil, fmt.Errorf("could not read flow rule %d of interface %s, err: %q", *installed[i].Loc,
+					iface, err)
+			}
+		}
+		if current == nil {
+			mismatches = append(mismatches, fmt.Sprintf("flow rule %d does not exist", i))
+			continue
+		}
+		if !rules[i].matches(current) {
+			mismatches = append(mismatches, fmt.Sprintf("flow rule %d is %s, expected %s", i, current.canonical(),
+				rules[i].canonical()))
+		}
+	}
+	return mismatches, nil
+}
+
+// ValidateFlowRules checks that device iface accepts the flow rules that es configures for classifier of interface
+// interfaceName, i.e. that offloading attribute "ntuple-filters" is on or is enabled by the settings, see
+// ValidateFeatures.
+func (es EthtoolConfigs) ValidateFlowRules(b Backend, interfaceName, classifier, iface string) (ValidationErrors,
+	error) {
+	key := interfaceName
+	if es[interfaceName].key != "" {
+		key = es[interfaceName].key
+	}
+	settings := es[interfaceName].Get(classifier)
+	if settings == nil || len(settings.FlowRules) == 0 {
+		return nil, nil
+	}
+	offloadList, err := b.ListFeatures(iface)
+	if err != nil {
+		return nil, fmt.Errorf("could not read offloading attributes of interface %s, err: %q", iface, err)
+	}
+	enabling := settings.Features[ntupleFeature] || settings.Features[ntupleKernelFeature]
+	if err := checkNtuple(iface, offloadList, enabling); err != nil {
+		value, _ := json.Marshal(settings.FlowRules)
+		return ValidationErrors{{
+			Path:  joinPath(configPath, key, classifier, flowRulesKey),
+			Value: string(value),
+			Rule:  err.Error(),
+		}}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,260 @@
+package ethtool
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/utils/pointer"
+)
+
+const (
+	ens3FlowRuleOutput = `Filter: 1023
+	Rule Type: TCP over IPv4
+	Src IP addr: 0.0.0.0 mask: 255.255.255.255
+	Dest IP addr: 192.0.2.0 mask: 0.0.0.255
+	TOS: 0x0 mask: 0xff
+	Src port: 0 mask: 0xffff
+	Dest port: 80 mask: 0x0
+	Action: Direct to queue 2
+
+`
+)
+
+func uint16Ptr(v uint16) *uint16 {
+	return &v
+}
+
+func TestExecBackendFlowRules(t *testing.T) {
+	fake := newFakeCommand(t, []string{"-n", "ens3", "rule", "1023"}, ens3FlowRuleOutput, "-N",
+		map[string]string{"-N ens3 flow-type": "Added rule with ID 1023\n"})
+
+	backend := execBackend{}
+	rule := &FlowRule{FlowType: "tcp4", DstIP: pointer.String("192.0.2.0/24"), DstPort: uint16Ptr(80),
+		Action: pointer.Int64(2)}
+	loc, err := backend.InsertFlowRule("ens3", rule)
+	if err != nil || loc != 1023 {
+		t.Fatalf("InsertFlowRule(ens3): expected location 1023 but got %d, err: %q", loc, err)
+	}
+	current, err := backend.GetFlowRule("ens3", 1023)
+	if err != nil {
+		t.Fatalf("GetFlowRule(ens3): expected to see no error but got %q", err)
+	}
+	expected := rule.Copy()
+	expected.Loc = pointer.Uint32(1023)
+	if !reflect.DeepEqual(current, expected) {
+		t.Fatalf("GetFlowRule(ens3): expected %v but got %v", expected, current)
+	}
+	if err := backend.DeleteFlowRule("ens3", 1023); err != nil {
+		t.Fatalf("DeleteFlowRule(ens3): expected to see no error but got %q", err)
+	}
+	if _, err := backend.InsertFlowRule("ens3", &FlowRule{FlowType: "ip6", SrcIP: pointer.String("2001:db8::/32"),
+		Action: pointer.Int64(FlowRuleActionDrop), Loc: pointer.Uint32(5)}); err != nil {
+		t.Fatalf("InsertFlowRule(ens3): expected to see no error but got %q", err)
+	}
+	expectedParameters := [][]string{
+		{"-N", "ens3", "flow-type", "tcp4", "dst-ip", "192.0.2.0", "m", "0.0.0.255", "dst-port", "80", "action", "2"},
+		{"-N", "ens3", "delete", "1023"},
+		{"-N", "ens3", "flow-type", "ip6", "src-ip", "2001:db8::", "m", "::ffff:ffff:ffff:ffff:ffff:ffff", "action",
+			"-1", "loc", "5"},
+	}
+	if !reflect.DeepEqual(fake.setParameters, expectedParameters) {
+		t.Fatalf("InsertFlowRule(ens3): expected parameters %v but got %v", expectedParameters, fake.setParameters)
+	}
+}
+
+func TestExecBackendDeleteNotFound(t *testing.T) {
+	ethtool = func(parameters ...string) ([]byte, error) {
+		if parameters[len(parameters)-1] == "delete" || parameters[len(parameters)-1] == "1" {
+			return nil, &exec.ExitError{Stderr: []byte("Cannot delete RX class rule: No such file or directory\n")}
+		}
+		return nil, &exec.ExitError{Stderr: []byte("rmgr: Cannot delete RX class rule: Operation not supported\n")}
+	}
+	defer func() { ethtool = fakeEthtool }()
+
+	backend := execBackend{}
+	if err := backend.DeleteFlowRule("ens3", 1); err != nil {
+		t.Fatalf("DeleteFlowRule(ens3): expected to see no error for a missing flow rule but got %q", err)
+	}
+	if rule, err := backend.GetFlowRule("ens3", 1); err != nil || rule != nil {
+		t.Fatalf("GetFlowRule(ens3): expected no flow rule at an empty location but got %v, err: %q", rule, err)
+	}
+	if err := backend.DeleteRSSContext("ens3", 1); err != nil {
+		t.Fatalf("DeleteRSSContext(ens3): expected to see no error for a missing RSS context but got %q", err)
+	}
+	if err := backend.DeleteFlowRule("ens3", 2); err == nil {
+		t.Fatalf("DeleteFlowRule(ens3): expected to see an error for an unsupported operation")
+	}
+}
+
+func TestMarshalFlowRule(t *testing.T) {
+	for _, rule := range []*FlowRule{
+		{FlowType: "tcp4", SrcIP: pointer.String("198.51.100.7/32"), DstIP: pointer.String("192.0.2.0/24"),
+			SrcPort: uint16Ptr(1024), DstPort: uint16Ptr(443), Action: pointer.Int64(3), Loc: pointer.Uint32(7)},
+		{FlowType: "ip4", DstIP: pointer.String("192.0.2.1/32"), Action: pointer.Int64(FlowRuleActionDrop),
+			Loc: pointer.Uint32(0)},
+		{FlowType: "udp6", DstIP: pointer.String("2001:db8::/64"), DstPort: uint16Ptr(53), Action: pointer.Int64(1),
+			Loc: pointer.Uint32(1)},
+	} {
+		b, err := marshalFlowRule(rule)
+		if err != nil {
+			t.Fatalf("marshalFlowRule(%v): expected to see no error but got %q", rule, err)
+		}
+		parsed, err := parseFlowRule(b)
+		if err != nil || !reflect.DeepEqual(parsed, rule) {
+			t.Fatalf("parseFlowRule(marshalFlowRule(%v)): expected the same rule but got %v, err: %q", rule, parsed,
+				err)
+		}
+	}
+}
+
+func TestValidateFlowRules(t *testing.T) {
+	ethtoolConfigs := EthtoolConfigs{"eth0": {
+		Self: &Settings{FlowRules: []FlowRule{
+			{FlowType: "tcp", DstPort: uint16Ptr(80), Action: pointer.Int64(1), Loc: pointer.Uint32(1)},
+			{FlowType: "ip4", SrcIP: pointer.String("2001:db8::1"), DstIP: pointer.String("192.0.2.0/33"),
+				DstPort: uint16Ptr(80), Action: pointer.Int64(-2)},
+			{FlowType: "udp6", Loc: pointer.Uint32(1)},
+		}},
+		Peer: &Settings{FlowRules: []FlowRule{}},
+	}}
+	var paths []string
+	for _, e := range ethtoolConfigs.Validate(ConfigVersionV1) {
+		paths = append(paths, e.Path)
+	}
+	expected := []string{"ethtool.eth0.peer", "ethtool.eth0.peer.flowRules", "ethtool.eth0.self.flowRules[0].flow-type",
+		"ethtool.eth0.self.flowRules[1].action", "ethtool.eth0.self.flowRules[1].dst-ip",
+		"ethtool.eth0.self.flowRules[1].dst-port", "ethtool.eth0.self.flowRules[1].src-ip",
+		"ethtool.eth0.self.flowRules[2].action", "ethtool.eth0.self.flowRules[2].loc"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Validate(%s): expected errors for %v but got %v", ethtoolConfigs, expected,
+			ethtoolConfigs.Validate(ConfigVersionV1))
+	}
+}
+
+func TestApplyFlowRules(t *testing.T) {
+	backend := newFakeBackend()
+	backend.features[ntupleFeature] = Offload{pointer.Bool(true), pointer.Bool(false), pointer.Bool(true)}
+	rules := []FlowRule{
+		{FlowType: "tcp4", DstIP: pointer.String("192.0.2.1"), DstPort: uint16Ptr(80), Action: pointer.Int64(1)},
+		{FlowType: "udp4", DstPort: uint16Ptr(53), Action: pointer.Int64(FlowRuleActionDrop), Loc: pointer.Uint32(9)},
+	}
+	installed, err := ApplyFlowRules(backend, "eth0", rules, nil)
+	if err != nil || !reflect.DeepEqual(flowRuleLocations(installed), []uint32{0, 9}) {
+		t.Fatalf("ApplyFlowRules: expected flow rules [0 9] but got %v, err: %q", installed, err)
+	}
+	if mismatches, err := CompareFlowRules(backend, "eth0", rules, installed); err != nil || len(mismatches) != 0 {
+		t.Fatalf("CompareFlowRules: expected no mismatches but got %v, err: %q", mismatches, err)
+	}
+	if mismatches, err := CompareFlowRules(backend, "eth0", rules, installed[:1]); err != nil ||
+		!reflect.DeepEqual(mismatches, []string{"flow rule 1 does not exist"}) {
+		t.Fatalf("CompareFlowRules: expected a missing flow rule but got %v, err: %q", mismatches, err)
+	}
+
+	// Applying again keeps the rules that match, replaces the others and deletes the ones that are no longer
+	// configured. Rules that this function did not install are left alone.
+	backend.flowRules[5] = &FlowRule{FlowType: "tcp6", Action: pointer.Int64(0), Loc: pointer.Uint32(5)}
+	rules = []FlowRule{{FlowType: "tcp4", DstIP: pointer.String("192.0.2.1/32"), DstPort: uint16Ptr(80),
+		Action: pointer.Int64(2)}}
+	installed, err = ApplyFlowRules(backend, "eth0", rules, installed)
+	if err != nil || !reflect.DeepEqual(flowRuleLocations(installed), []uint32{0}) {
+		t.Fatalf("ApplyFlowRules: expected flow rules [0] but got %v, err: %q", installed, err)
+	}
+	if _, ok := backend.flowRules[9]; ok {
+		t.Fatalf("ApplyFlowRules: expected flow rule 9 to be deleted")
+	}
+	if mismatches, err := CompareFlowRules(backend, "eth0", rules, installed); err != nil || len(mismatches) != 0 {
+		t.Fatalf("CompareFlowRules: expected no mismatches but got %v, err: %q", mismatches, err)
+	}
+	if err := DeleteFlowRules(backend, "eth0", installed); err != nil || len(backend.flowRules) != 1 {
+		t.Fatalf("DeleteFlowRules: expected only flow rule 5 to remain but got %v, err: %q", backend.flowRules, err)
+	}
+	// Rules that are already gone, e.g. after a DEL that did not finish, do not fail a retry.
+	if err := DeleteFlowRules(backend, "eth0", installed); err != nil {
+		t.Fatalf("DeleteFlowRules: expected to see no error for deleted flow rules but got %q", err)
+	}
+
+	// An RX ring that does not exist fails, the rules that exist at that point are returned.
+	rules = append(rules, FlowRule{FlowType: "tcp4", Action: pointer.Int64(8)})
+	installed, err = ApplyFlowRules(backend, "eth0", rules, nil)
+	if err == nil || !reflect.DeepEqual(flowRuleLocations(installed), []uint32{0}) {
+		t.Fatalf("ApplyFlowRules: expected an error and flow rules [0] but got %v, err: %v", installed, err)
+	}
+}
+
+func TestFlowRulesOfOthers(t *testing.T) {
+	backend := newFakeBackend()
+	backend.features[ntupleFeature] = Offload{pointer.Bool(true), pointer.Bool(false), pointer.Bool(true)}
+	rules := []FlowRule{{FlowType: "tcp4", DstPort: uint16Ptr(80), Action: pointer.Int64(1), Loc: pointer.Uint32(3)}}
+	installed, err := ApplyFlowRules(backend, "eth0", rules, nil)
+	if err != nil {
+		t.Fatalf("ApplyFlowRules: expected to see no error but got %q", err)
+	}
+
+	// A location that another rule uses, e.g. a rule of another pod on the same physical function, fails.
+	if _, err := ApplyFlowRules(backend, "eth0", rules, nil); err == nil ||
+		!strings.Contains(err.Error(), "location 3 is in use") {
+		t.Fatalf("ApplyFlowRules: expected an error for a location in use but got %v", err)
+	}
+
+	// A rule that was replaced outside of the plugin is neither deleted nor replaced.
+	other := &FlowRule{FlowType: "udp4", DstPort: uint16Ptr(53), Action: pointer.Int64(0), Loc: pointer.Uint32(3)}
+	backend.flowRules[3] = other
+	if err := DeleteFlowRules(backend, "eth0", installed); err != nil || backend.flowRules[3] != other {
+		t.Fatalf("DeleteFlowRules: expected to keep the flow rule of others but got %v, err: %q",
+			backend.flowRules, err)
+	}
+	if _, err := ApplyFlowRules(backend, "eth0", rules, installed); err == nil || backend.flowRules[3] != other {
+		t.Fatalf("ApplyFlowRules: expected to keep the flow rule of others but got %v, err: %v",
+			backend.flowRules, err)
+	}
+}
+
+// flowRuleLocations returns the locations of installed.
+func flowRuleLocations(installed []FlowRule) []uint32 {
+	var locs []uint32
+	for _, rule := range installed {
+		locs = append(locs, *rule.Loc)
+	}
+	return locs
+}
+
+func TestApplyFlowRulesNtuple(t *testing.T) {
+	backend := newFakeBackend()
+	rules := []FlowRule{{FlowType: "tcp4", Action: pointer.Int64(1)}}
+	for _, tc := range []struct {
+		offload *Offload
+		errStr  string
+	}{
+		{nil, `offloading attribute "ntuple-filters" is off [fixed]`},
+		{&Offload{pointer.Bool(false), pointer.Bool(true), pointer.Bool(false)}, "is off [fixed]"},
+		{&Offload{pointer.Bool(false), pointer.Bool(false), pointer.Bool(false)}, `enable it in section "features"`},
+	} {
+		delete(backend.features, ntupleFeature)
+		if tc.offload != nil {
+			backend.features[ntupleFeature] = *tc.offload
+		}
+		installed, err := ApplyFlowRules(backend, "eth0", rules, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.errStr) || len(installed) != 0 {
+			t.Fatalf("ApplyFlowRules(%v): expected to see error %q but got %v, err: %v", tc.offload, tc.errStr,
+				installed, err)
+		}
+	}
+
+	// Validation accepts rules if the settings enable ntuple filters, under either name.
+	for _, name := range []string{ntupleFeature, ntupleKernelFeature} {
+		ethtoolConfigs := EthtoolConfigs{"eth0": {Self: &Settings{Features: map[string]bool{name: true},
+			FlowRules: rules}}}
+		if errs, err := ethtoolConfigs.ValidateFlowRules(backend, "eth0", SelfClassifier, "eth0"); err != nil ||
+			errs != nil {
+			t.Fatalf("ValidateFlowRules(%s): expected no errors but got %v, err: %q", ethtoolConfigs, errs, err)
+		}
+	}
+	ethtoolConfigs := EthtoolConfigs{"eth0": {Self: &Settings{FlowRules: rules}}}
+	errs, err := ethtoolConfigs.ValidateFlowRules(backend, "eth0", SelfClassifier, "eth0")
+	if err != nil || len(errs) != 1 || errs[0].Path != "ethtool.eth0.self.flowRules" {
+		t.Fatalf("ValidateFlowRules(%s): expected an error for ethtool.eth0.self.flowRules but got %v, err: %q",
+			ethtoolConfigs, errs, err)
+	}
+}
@@ -0,0 +1,343 @@
+package ethtool
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Constants of the ethtool ioctl interface, see include/uapi/linux/ethtool.h. They are used for the settings that the
+// ethtool generic netlink family of older kernels cannot change.
+const (
+	ethtoolGRXRings = 0x2d
+	ethtoolGRSSH    = 0x46
+	ethtoolSRSSH    = 0x47
+
+	// ethRXFHContextAlloc creates a new RSS context with ETHTOOL_SRSSH.
+	ethRXFHContextAlloc = 0xffffffff
+	// ethRXFHIndirNoChange leaves the indirection table unchanged with ETHTOOL_SRSSH.
+	ethRXFHIndirNoChange = 0xffffffff
+
+	// rxfhHeaderSize is the size of struct ethtool_rxfh without its variable-length rss_config.
+	rxfhHeaderSize = 24
+	// rxnfcSize is the size of struct ethtool_rxnfc without its variable-length rule_locs.
+	rxnfcSize = 192
+)
+
+// ifreq is struct ifreq with the ifr_data member that ethtool ioctls use.
+type ifreq struct {
+	name [unix.IFNAMSIZ]byte
+	data unsafe.Pointer
+	_    [16]byte
+}
+
+// ethtoolIoctl runs the ethtool ioctl whose command and arguments are in data on interface iface in the network
+// namespace of the calling thread.
+func ethtoolIoctl(iface string, data []byte) error {
+	if len(iface) >= unix.IFNAMSIZ {
+		return fmt.Errorf("invalid interface name %q", iface)
+	}
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("could not open socket, err: %q", err)
+	}
+	defer unix.Close(fd)
+	ifr := ifreq{data: unsafe.Pointer(&data[0])}
+	copy(ifr.name[:], iface)
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.SIOCETHTOOL,
+		uintptr(unsafe.Pointer(&ifr))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// rxfh is struct ethtool_rxfh, see ETHTOOL_GRSSH and ETHTOOL_SRSSH.
+type rxfh struct {
+	context   uint32
+	indirSize uint32
+	keySize   uint32
+	hfunc     uint8
+	// indir and key are the indirection table and the hash key. They are only sent if indirSize and keySize are the
+	// sizes of the device.
+	indir []uint32
+	key   []byte
+}
+
+func (r *rxfh) marshal(cmd uint32) []byte {
+	b := make([]byte, rxfhHeaderSize+4*len(r.indir)+len(r.key))
+	binary.NativeEndian.PutUint32(b[0:], cmd)
+	binary.NativeEndian.PutUint32(b[4:], r.context)
+	binary.NativeEndian.PutUint32(b[8:], r.indirSize)
+	binary.NativeEndian.PutUint32(b[12:], r.keySize)
+	b[16] = r.hfunc
+	for i, ring := range r.indir {
+		binary.NativeEndian.PutUint32(b[rxfhHeaderSize+4*i:], ring)
+	}
+	copy(b[rxfhHeaderSize+4*len(r.indir):], r.key)
+	return b
+}
+
+// getRXFH returns the indirection table, the hash key and the hash function of RSS context context of iface.
+func getRXFH(iface string, context uint32) (*rxfh, error) {
+	// The first call only returns the sizes of the indirection table and of the hash key.
+	sizes := (&rxfh{context: context}).marshal(ethtoolGRSSH)
+	if err := ethtoolIoctl(iface, sizes); err != nil {
+		return nil, err
+	}
+	r := &rxfh{
+		context:   context,
+		indirSize: binary.NativeEndian.Uint32(sizes[8:]),
+		keySize:   binary.NativeEndian.Uint32(sizes[12:]),
+	}
+	r.indir, r.key = make([]uint32, r.indirSize), make([]byte, r.keySize)
+	b := r.marshal(ethtoolGRSSH)
+	if err := ethtoolIoctl(iface, b); err != nil {
+		return nil, err
+	}
+	r.hfunc = b[16]
+	for i := range r.indir {
+		r.indir[i] = binary.NativeEndian.Uint32(b[rxfhHeaderSize+4*i:])
+	}
+	copy(r.key, b[rxfhHeaderSize+4*len(r.indir):])
+	return r, nil
+}
+
+// setRXFH changes RSS context r.context of iface and returns the ID of the context, which differs from r.context if
+// it is ethRXFHContextAlloc.
+func setRXFH(iface string, r *rxfh) (uint32, error) {
+	b := r.marshal(ethtoolSRSSH)
+	if err := ethtoolIoctl(iface, b); err != nil {
+		return 0, err
+	}
+	return binary.NativeEndian.Uint32(b[4:]), nil
+}
+
+// getRXRings returns the number of RX rings of iface that RSS and flow rules may use.
+func getRXRings(iface string) (uint32, error) {
+	b := make([]byte, rxnfcSize)
+	binary.NativeEndian.PutUint32(b[0:], ethtoolGRXRings)
+	if err := ethtoolIoctl(iface, b); err != nil {
+		return 0, err
+	}
+	return uint32(binary.NativeEndian.Uint64(b[8:])), nil
+}
+
+// Constants of the ethtool ioctl interface for flow rules, see struct ethtool_rx_flow_spec.
+const (
+	ethtoolGRXClsRlCnt = 0x2e
+	ethtoolGRXClsRule  = 0x2f
+	ethtoolGRXClsRlAll = 0x30
+	ethtoolSRXClsRlDel = 0x31
+	ethtoolSRXClsRlIns = 0x32
+
+	ethtoolTCPV4Flow    = 0x01
+	ethtoolUDPV4Flow    = 0x02
+	ethtoolSCTPV4Flow   = 0x03
+	ethtoolTCPV6Flow    = 0x05
+	ethtoolUDPV6Flow    = 0x06
+	ethtoolSCTPV6Flow   = 0x07
+	ethtoolIPV4UserFlow = 0x0d
+	ethtoolIPV6UserFlow = 0x0e
+	// flowTypeFlags are the FLOW_EXT, FLOW_MAC_EXT and FLOW_RSS flags of flow_type.
+	flowTypeFlags = 0xe0000000
+	// ethRXNFCIP4 is the ip_ver of IPV4_USER_FLOW rules.
+	ethRXNFCIP4 = 1
+
+	// rxClsLocAny lets the driver select the location of a new rule.
+	rxClsLocAny = 0xffffffff
+	// rxClsLocSpecial is set in the table size that ETHTOOL_GRXCLSRLCNT returns if the driver supports rxClsLocAny.
+	rxClsLocSpecial = 0x80000000
+	// rxClsFlowDisc is the ring_cookie of rules that drop matching packets.
+	rxClsFlowDisc = 0xffffffffffffffff
+
+	// Offsets of the members of struct ethtool_rxnfc, and of the members of struct ethtool_rx_flow_spec inside it.
+	rxnfcData            = 8
+	rxnfcFlowSpec        = 16
+	rxnfcRuleCnt         = 184
+	rxnfcRuleLocs        = 188
+	flowSpecHeader       = 4
+	flowSpecMask         = 76
+	flowSpecRingCookie   = 152
+	flowSpecLocation     = 160
+	flowSpecIPV4Dst      = 4
+	flowSpecIPV4Ports    = 8
+	flowSpecIPV4UserVer  = 13
+	flowSpecIPV6Dst      = 16
+	flowSpecIPV6Ports    = 32
+	flowSpecPortDstShift = 2
+)
+
+// insertFlowRule installs rule on iface and returns its location. If rule does not have a location, it selects one
+// with flowRuleLocation.
+func insertFlowRule(iface string, rule *FlowRule) (uint32, error) {
+	if rule.Loc == nil {
+		loc, err := flowRuleLocation(iface)
+		if err != nil {
+			return 0, err
+		}
+		rule = rule.Copy()
+		rule.Loc = &loc
+	}
+	b, err := marshalFlowRule(rule)
+	if err != nil {
+		return 0, err
+	}
+	binary.NativeEndian.PutUint32(b[0:], ethtoolSRXClsRlIns)
+	if err := ethtoolIoctl(iface, b); err != nil {
+		return 0, err
+	}
+	return binary.NativeEndian.Uint32(b[rxnfcFlowSpec+flowSpecLocation:]), nil
+}
+
+// flowRuleLocation returns the location for a new flow rule of iface. Like the ethtool CLI, it lets the driver select
+// the location if the driver supports it. Most drivers, e.g. ixgbe, i40e and igb, reject rxClsLocAny, so it selects
+// the last free location of the rule table otherwise.
+func flowRuleLocation(iface string) (uint32, error) {
+	b := make([]byte, rxnfcSize)
+	binary.NativeEndian.PutUint32(b[0:], ethtoolGRXClsRlCnt)
+	if err := ethtoolIoctl(iface, b); err != nil {
+		return 0, fmt.Errorf("could not get the number of flow rules, err: %q", err)
+	}
+	size := uint32(binary.NativeEndian.Uint64(b[rxnfcData:]))
+	if size&rxClsLocSpecial != 0 {
+		return rxClsLocAny, nil
+	}
+	count := binary.NativeEndian.Uint32(b[rxnfcRuleCnt:])
+	b = make([]byte, max(rxnfcSize, rxnfcRuleLocs+4*int(count)))
+	binary.NativeEndian.PutUint32(b[0:], ethtoolGRXClsRlAll)
+	binary.NativeEndian.PutUint32(b[rxnfcRuleCnt:], count)
+	if err := ethtoolIoctl(iface, b); err != nil {
+		return 0, fmt.Errorf("could not list the flow rules, err: %q", err)
+	}
+	used := make([]uint32, binary.NativeEndian.Uint32(b[rxnfcRuleCnt:]))
+	for i := range used {
+		used[i] = binary.NativeEndian.Uint32(b[rxnfcRuleLocs+4*i:])
+	}
+	return lastFreeLocation(size, used)
+}
+
+// lastFreeLocation returns the highest location of a rule table with size locations that is not in used. Rules at
+// higher locations have a lower priority.
+func lastFreeLocation(size uint32, used []uint32) (uint32, error) {
+	for loc := size; loc > 0; loc-- {
+		if !slices.Contains(used, loc-1) {
+			return loc - 1, nil
+		}
+	}
+	return 0, fmt.Errorf("all %d locations of the flow rule table are in use", size)
+}
+
+// deleteFlowRule deletes the flow rule at location loc of iface.
+func deleteFlowRule(iface string, loc uint32) error {
+	b := make([]byte, rxnfcSize)
+	binary.NativeEndian.PutUint32(b[0:], ethtoolSRXClsRlDel)
+	binary.NativeEndian.PutUint32(b[rxnfcFlowSpec+flowSpecLocation:], loc)
+	return ethtoolIoctl(iface, b)
+}
+
+// getFlowRule returns the flow rule at location loc of iface.
+func getFlowRule(iface string, loc uint32) (*FlowRule, error) {
+	b := make([]byte, rxnfcSize)
+	binary.NativeEndian.PutUint32(b[0:], ethtoolGRXClsRule)
+	binary.NativeEndian.PutUint32(b[rxnfcFlowSpec+flowSpecLocation:], loc)
+	if err := ethtoolIoctl(iface, b); err != nil {
+		return nil, err
+	}
+	return parseFlowRule(b)
+}
+
+// marshalFlowRule returns struct ethtool_rxnfc for rule. Addresses and ports are in network byte order, a mask bit of
+// 1 compares the bit.
+func marshalFlowRule(rule *FlowRule) ([]byte, error) {
+	flowType, ok := flowTypes[rule.FlowType]
+	if !ok {
+		return nil, fmt.Errorf("unknown flow type %q", rule.FlowType)
+	}
+	b := make([]byte, rxnfcSize)
+	fs := b[rxnfcFlowSpec:]
+	binary.NativeEndian.PutUint32(fs[0:], flowType.value)
+	value, mask := fs[flowSpecHeader:], fs[flowSpecMask:]
+	dst, ports := flowSpecIPV4Dst, flowSpecIPV4Ports
+	if flowType.ipv6 {
+		dst, ports = flowSpecIPV6Dst, flowSpecIPV6Ports
+	}
+	for offset, ip := range map[int]*string{0: rule.SrcIP, dst: rule.DstIP} {
+		if ip == nil {
+			continue
+		}
+		prefix, err := parsePrefix(*ip)
+		if err != nil {
+			return nil, err
+		}
+		copy(value[offset:], prefix.Masked().Addr().AsSlice())
+		copy(mask[offset:], prefixMask(prefix))
+	}
+	for offset, port := range map[int]*uint16{ports: rule.SrcPort, ports + flowSpecPortDstShift: rule.DstPort} {
+		if port != nil {
+			binary.BigEndian.PutUint16(value[offset:], *port)
+			binary.BigEndian.PutUint16(mask[offset:], 0xffff)
+		}
+	}
+	if flowType.value == ethtoolIPV4UserFlow {
+		value[flowSpecIPV4UserVer] = ethRXNFCIP4
+	}
+	cookie := uint64(rxClsFlowDisc)
+	if rule.Action != nil && *rule.Action != FlowRuleActionDrop {
+		cookie = uint64(*rule.Action)
+	}
+	binary.NativeEndian.PutUint64(fs[flowSpecRingCookie:], cookie)
+	loc := uint32(rxClsLocAny)
+	if rule.Loc != nil {
+		loc = *rule.Loc
+	}
+	binary.NativeEndian.PutUint32(fs[flowSpecLocation:], loc)
+	return b, nil
+}
+
+// parseFlowRule parses struct ethtool_rxnfc into a flow rule. Fields with an empty mask are not set.
+func parseFlowRule(b []byte) (*FlowRule, error) {
+	fs := b[rxnfcFlowSpec:]
+	value := binary.NativeEndian.Uint32(fs[0:]) &^ flowTypeFlags
+	rule := &FlowRule{}
+	for name, flowType := range flowTypes {
+		if flowType.value != value {
+			continue
+		}
+		rule.FlowType = name
+		values, mask := fs[flowSpecHeader:], fs[flowSpecMask:]
+		dst, ports, size := flowSpecIPV4Dst, flowSpecIPV4Ports, 4
+		if flowType.ipv6 {
+			dst, ports, size = flowSpecIPV6Dst, flowSpecIPV6Ports, 16
+		}
+		for offset, ip := range map[int]**string{0: &rule.SrcIP, dst: &rule.DstIP} {
+			if prefix, ok := prefixFromMask(values[offset:offset+size], mask[offset:offset+size]); ok {
+				s := prefix.String()
+				*ip = &s
+			}
+		}
+		if flowType.ports {
+			dstPort := ports + flowSpecPortDstShift
+			for offset, port := range map[int]**uint16{ports: &rule.SrcPort, dstPort: &rule.DstPort} {
+				if binary.BigEndian.Uint16(mask[offset:]) == 0xffff {
+					v := binary.BigEndian.Uint16(values[offset:])
+					*port = &v
+				}
+			}
+		}
+	}
+	if rule.FlowType == "" {
+		return nil, fmt.Errorf("unsupported flow type %#x", value)
+	}
+	action := int64(FlowRuleActionDrop)
+	if cookie := binary.NativeEndian.Uint64(fs[flowSpecRingCookie:]); cookie != rxClsFlowDisc {
+		// The upper 32 bits select a virtual function, which flow rules of the plugin never do.
+		action = int64(uint32(cookie))
+	}
+	rule.Action = &action
+	loc := binary.NativeEndian.Uint32(fs[flowSpecLocation:])
+	rule.Loc = &loc
+	return rule, nil
+}
@@ -0,0 +1,124 @@
+package ethtool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/pointer"
+)
+
+func TestRXFHMarshal(t *testing.T) {
+	r := &rxfh{context: 3, indirSize: 4, keySize: 2, hfunc: 2, indir: []uint32{0, 1, 0, 1}, key: []byte{0xab, 0xcd}}
+	// struct ethtool_rxfh: cmd, rss_context, indir_size, key_size, hfunc, 7 reserved bytes, then the indirection table
+	// and the hash key.
+	expected := make([]byte, 24+4*4+2)
+	binary.NativeEndian.PutUint32(expected[0:], 0x47)
+	binary.NativeEndian.PutUint32(expected[4:], 3)
+	binary.NativeEndian.PutUint32(expected[8:], 4)
+	binary.NativeEndian.PutUint32(expected[12:], 2)
+	expected[16] = 2
+	binary.NativeEndian.PutUint32(expected[28:], 1)
+	binary.NativeEndian.PutUint32(expected[36:], 1)
+	expected[40], expected[41] = 0xab, 0xcd
+	if b := r.marshal(ethtoolSRSSH); !bytes.Equal(b, expected) {
+		t.Fatalf("marshal(%+v): expected %v but got %v", r, expected, b)
+	}
+
+	// A reset of the indirection table sends neither the table nor the key.
+	expected = make([]byte, 24)
+	binary.NativeEndian.PutUint32(expected[0:], 0x47)
+	if b := (&rxfh{}).marshal(ethtoolSRSSH); !bytes.Equal(b, expected) {
+		t.Fatalf("marshal: expected %v but got %v", expected, b)
+	}
+}
+
+func TestFlowRuleLayout(t *testing.T) {
+	tcs := []struct {
+		rule     *FlowRule
+		expected func(b []byte)
+	}{
+		{
+			&FlowRule{FlowType: "tcp4", SrcPort: uint16Ptr(1024), DstIP: pointer.String("192.0.2.0/24"),
+				DstPort: uint16Ptr(80), Action: pointer.Int64(2), Loc: pointer.Uint32(5)},
+			// struct ethtool_rxnfc: cmd, flow_type, data, then struct ethtool_rx_flow_spec at offset 16 with
+			// flow_type, h_u at 4, h_ext at 56, m_u at 76, m_ext at 128, ring_cookie at 152 and location at 160.
+			func(b []byte) {
+				binary.NativeEndian.PutUint32(b[16:], 0x01)
+				copy(b[24:], []byte{192, 0, 2, 0})
+				copy(b[96:], []byte{255, 255, 255, 0})
+				binary.BigEndian.PutUint16(b[28:], 1024)
+				binary.BigEndian.PutUint16(b[100:], 0xffff)
+				binary.BigEndian.PutUint16(b[30:], 80)
+				binary.BigEndian.PutUint16(b[102:], 0xffff)
+				binary.NativeEndian.PutUint64(b[168:], 2)
+				binary.NativeEndian.PutUint32(b[176:], 5)
+			},
+		},
+		{
+			&FlowRule{FlowType: "udp6", SrcIP: pointer.String("2001:db8::/32"), DstPort: uint16Ptr(53),
+				Action: pointer.Int64(FlowRuleActionDrop), Loc: pointer.Uint32(7)},
+			func(b []byte) {
+				binary.NativeEndian.PutUint32(b[16:], 0x06)
+				copy(b[20:], []byte{0x20, 0x01, 0x0d, 0xb8})
+				copy(b[92:], []byte{255, 255, 255, 255})
+				binary.BigEndian.PutUint16(b[54:], 53)
+				binary.BigEndian.PutUint16(b[126:], 0xffff)
+				binary.NativeEndian.PutUint64(b[168:], 0xffffffffffffffff)
+				binary.NativeEndian.PutUint32(b[176:], 7)
+			},
+		},
+		{
+			&FlowRule{FlowType: "ip4", SrcIP: pointer.String("198.51.100.7/32"), Action: pointer.Int64(0),
+				Loc: pointer.Uint32(0)},
+			func(b []byte) {
+				binary.NativeEndian.PutUint32(b[16:], 0x0d)
+				copy(b[20:], []byte{198, 51, 100, 7})
+				copy(b[92:], []byte{255, 255, 255, 255})
+				// ip_ver of struct ethtool_usrip4_spec.
+				b[33] = 1
+				binary.NativeEndian.PutUint32(b[176:], 0)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		expected := make([]byte, 192)
+		tc.expected(expected)
+		b, err := marshalFlowRule(tc.rule)
+		if err != nil {
+			t.Fatalf("marshalFlowRule(%v): expected to see no error but got %q", tc.rule, err)
+		}
+		if !bytes.Equal(b, expected) {
+			t.Fatalf("marshalFlowRule(%v): expected %v but got %v", tc.rule, expected, b)
+		}
+		rule, err := parseFlowRule(expected)
+		if err != nil {
+			t.Fatalf("parseFlowRule(%v): expected to see no error but got %q", expected, err)
+		}
+		if !reflect.DeepEqual(rule, tc.rule) {
+			t.Fatalf("parseFlowRule(%v): expected %v but got %v", expected, tc.rule, rule)
+		}
+	}
+}
+
+func TestLastFreeLocation(t *testing.T) {
+	tcs := []struct {
+		size     uint32
+		used     []uint32
+		expected uint32
+	}{
+		{1024, nil, 1023},
+		{1024, []uint32{1023, 1022, 5}, 1021},
+		{4, []uint32{3, 1, 2}, 0},
+	}
+	for _, tc := range tcs {
+		if loc, err := lastFreeLocation(tc.size, tc.used); err != nil || loc != tc.expected {
+			t.Fatalf("lastFreeLocation(%d, %v): expected %d but got %d, err: %q", tc.size, tc.used, tc.expected,
+				loc, err)
+		}
+	}
+	if _, err := lastFreeLocation(2, []uint32{0, 1}); err == nil {
+		t.Fatalf("lastFreeLocation(2, [0 1]): expected to see an error for a full table")
+	}
+}
@@ -0,0 +1,261 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+const (
+	LinkSpeed     = "speed"
+	LinkDuplex    = "duplex"
+	LinkAutoneg   = "autoneg"
+	LinkAdvertise = "advertise"
+	LinkMDIX      = "mdix"
+
+	DuplexHalf = "half"
+	DuplexFull = "full"
+
+	MDIXAuto = "auto"
+	MDIXOn   = "on"
+	MDIXOff  = "off"
+
+	// linkModeAutoneg is the link mode that devices report if they support autonegotiation.
+	linkModeAutoneg = "Autoneg"
+)
+
+// linkModeRegexp matches the link modes that stand for a speed and a duplex, e.g. "10000baseT/Full". Other link modes,
+// e.g. "Pause" or "FIBRE", describe capabilities of the device.
+var linkModeRegexp = regexp.MustCompile(`^(\d+)base[^/]*/(Half|Full)$`)
+
+// duplexValues maps the duplex names to their ETHTOOL_A_LINKMODES_DUPLEX values.
+var duplexValues = map[string]uint8{DuplexHalf: 0, DuplexFull: 1}
+
+// mdixValues maps the MDI-X names to their ETHTOOL_A_LINKINFO_TP_MDIX_CTRL values.
+var mdixValues = map[string]uint8{MDIXOff: 1, MDIXOn: 2, MDIXAuto: 3}
+
+// Link holds the link settings of an interface, see ethtool -s. Settings that are not set are not changed.
+type Link struct {
+	// Speed is the forced speed in Mb/s. With autonegotiation, the device advertises all link modes of this speed.
+	Speed *uint32 `json:"speed,omitempty"`
+	// Duplex is either DuplexHalf or DuplexFull.
+	Duplex *string `json:"duplex,omitempty"`
+	// Autoneg enables or disables autonegotiation.
+	Autoneg *bool `json:"autoneg,omitempty"`
+	// Advertise lists the link modes that the device advertises during autonegotiation, e.g. "25000baseCR/Full". All
+	// other link modes of the device are no longer advertised. Capabilities such as "Pause" are not changed.
+	Advertise []string `json:"advertise,omitempty"`
+	// MDIX is either MDIXAuto, MDIXOn or MDIXOff. It only applies to twisted pair ports.
+	MDIX *string `json:"mdix,omitempty"`
+}
+
+// IsEmpty returns true if no link setting is set.
+func (l *Link) IsEmpty() bool {
+	return l == nil || (l.Speed == nil && l.Duplex == nil && l.Autoneg == nil && len(l.Advertise) == 0 &&
+		l.MDIX == nil)
+}
+
+// configuresLinkModes returns true if l changes the link modes of the device, i.e. anything but MDI-X.
+func (l *Link) configuresLinkModes() bool {
+	return l.Speed != nil || l.Duplex != nil || l.Autoneg != nil || len(l.Advertise) > 0
+}
+
+// Copy returns a deep copy of l.
+func (l *Link) Copy() *Link {
+	if l == nil {
+		return nil
+	}
+	copied := &Link{Advertise: slices.Clone(l.Advertise)}
+	if l.Speed != nil {
+		v := *l.Speed
+		copied.Speed = &v
+	}
+	if l.Duplex != nil {
+		v := *l.Duplex
+		copied.Duplex = &v
+	}
+	if l.Autoneg != nil {
+		v := *l.Autoneg
+		copied.Autoneg = &v
+	}
+	if l.MDIX != nil {
+		v := *l.MDIX
+		copied.MDIX = &v
+	}
+	return copied
+}
+
+// validateSchema checks the values of l that do not depend on the device and returns every problem that it finds.
+// path is the JSON path of l.
+func (l *Link) validateSchema(path string) ValidationErrors {
+	var errs ValidationErrors
+	if l.Speed != nil && *l.Speed == 0 {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, LinkSpeed),
+			Value: "0",
+			Rule:  "must be greater than 0",
+		})
+	}
+	if l.Duplex != nil {
+		if _, ok := duplexValues[*l.Duplex]; !ok {
+			errs = append(errs, ValidationError{
+				Path:  joinPath(path, LinkDuplex),
+				Value: fmt.Sprintf("%q", *l.Duplex),
+				Rule:  fmt.Sprintf("must be one of %q", []string{DuplexHalf, DuplexFull}),
+			})
+		}
+	}
+	if l.MDIX != nil {
+		if _, ok := mdixValues[*l.MDIX]; !ok {
+			errs = append(errs, ValidationError{
+				Path:  joinPath(path, LinkMDIX),
+				Value: fmt.Sprintf("%q", *l.MDIX),
+				Rule:  fmt.Sprintf("must be one of %q", []string{MDIXAuto, MDIXOn, MDIXOff}),
+			})
+		}
+	}
+	for i, mode := range l.Advertise {
+		if !linkModeRegexp.MatchString(mode) {
+			errs = append(errs, ValidationError{
+				Path:  fmt.Sprintf("%s[%d]", joinPath(path, LinkAdvertise), i),
+				Value: fmt.Sprintf("%q", mode),
+				Rule:  fmt.Sprintf("must be a link mode with a speed and a duplex, e.g. %q", "10000baseT/Full"),
+			})
+		}
+	}
+	if len(l.Advertise) > 0 && l.Autoneg != nil && !*l.Autoneg {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, LinkAdvertise),
+			Value: fmt.Sprintf("%q", l.Advertise),
+			Rule:  fmt.Sprintf("requires %q", LinkAutoneg),
+		})
+	}
+	return errs
+}
+
+// validate makes sure that the device supports every configured link setting.
+func (l *Link) validate(params *LinkParameters) error {
+	if l.Autoneg != nil && *l.Autoneg && !slices.Contains(params.Supported, linkModeAutoneg) {
+		return fmt.Errorf("device does not support autonegotiation")
+	}
+	for _, mode := range l.Advertise {
+		if !slices.Contains(params.Supported, mode) {
+			return fmt.Errorf("device does not support link mode %q, supported link modes are %q", mode,
+				speedModes(params.Supported))
+		}
+	}
+	if l.Speed == nil && l.Duplex == nil {
+		return nil
+	}
+	for _, mode := range speedModes(params.Supported) {
+		speed, duplex := parseLinkMode(mode)
+		if (l.Speed == nil || *l.Speed == speed) && (l.Duplex == nil || *l.Duplex == duplex) {
+			return nil
+		}
+	}
+	return fmt.Errorf("device does not support speed %s and duplex %s, supported link modes are %q",
+		optionalString(l.Speed), optionalString(l.Duplex), speedModes(params.Supported))
+}
+
+// isCurrent returns true if current already holds every setting of l, so that applying l would not change anything.
+// Unlike diff, the speed and the duplex must be known.
+func (l *Link) isCurrent(current *Link) bool {
+	equal := func(a, b *string) bool { return a == nil || (b != nil && *a == *b) }
+	return (l.Speed == nil || (current.Speed != nil && *l.Speed == *current.Speed)) &&
+		equal(l.Duplex, current.Duplex) && equal(l.MDIX, current.MDIX) &&
+		(l.Autoneg == nil || (current.Autoneg != nil && *l.Autoneg == *current.Autoneg)) &&
+		(len(l.Advertise) == 0 || slices.Equal(speedModes(l.Advertise), speedModes(current.Advertise)))
+}
+
+// diff returns a description of every setting that is set in l and whose value in current differs. The speed and the
+// duplex are only compared if the device reports them, i.e. while the link is up.
+func (l *Link) diff(current *Link) []string {
+	var mismatches []string
+	if l.Speed != nil && current.Speed != nil && *l.Speed != *current.Speed {
+		mismatches = append(mismatches, fmt.Sprintf("link setting %q is %d, expected %d", LinkSpeed,
+			*current.Speed, *l.Speed))
+	}
+	if l.Duplex != nil && current.Duplex != nil && *l.Duplex != *current.Duplex {
+		mismatches = append(mismatches, fmt.Sprintf("link setting %q is %s, expected %s", LinkDuplex,
+			*current.Duplex, *l.Duplex))
+	}
+	if l.Autoneg != nil {
+		if current.Autoneg == nil {
+			mismatches = append(mismatches, fmt.Sprintf("link setting %q is not supported", LinkAutoneg))
+		} else if *l.Autoneg != *current.Autoneg {
+			mismatches = append(mismatches, fmt.Sprintf("link setting %q is %s, expected %s", LinkAutoneg,
+				status[*current.Autoneg], status[*l.Autoneg]))
+		}
+	}
+	if len(l.Advertise) > 0 {
+		expected, advertised := speedModes(l.Advertise), speedModes(current.Advertise)
+		if !slices.Equal(expected, advertised) {
+			mismatches = append(mismatches, fmt.Sprintf("link setting %q is %q, expected %q", LinkAdvertise,
+				advertised, expected))
+		}
+	}
+	if l.MDIX != nil {
+		if current.MDIX == nil {
+			mismatches = append(mismatches, fmt.Sprintf("link setting %q is not supported", LinkMDIX))
+		} else if *l.MDIX != *current.MDIX {
+			mismatches = append(mismatches, fmt.Sprintf("link setting %q is %s, expected %s", LinkMDIX,
+				*current.MDIX, *l.MDIX))
+		}
+	}
+	return mismatches
+}
+
+func (l Link) String() string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// LinkParameters are the link settings that a device reports. In Current, Advertise holds all advertised link modes,
+// the speed and the duplex are nil while the link is down and MDIX is nil if the device does not support it.
+type LinkParameters struct {
+	Current Link
+	// Supported lists the link modes that the device supports, including capabilities such as "Autoneg".
+	Supported []string
+}
+
+// speedModes returns the link modes that stand for a speed and a duplex, in alphabetical order.
+func speedModes(modes []string) []string {
+	var result []string
+	for _, mode := range modes {
+		if linkModeRegexp.MatchString(mode) {
+			result = append(result, mode)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// parseLinkMode returns the speed in Mb/s and the duplex of a link mode that matches linkModeRegexp.
+func parseLinkMode(mode string) (uint32, string) {
+	match := linkModeRegexp.FindStringSubmatch(mode)
+	if match == nil {
+		return 0, ""
+	}
+	speed, err := strconv.ParseUint(match[1], 10, 32)
+	if err != nil {
+		return 0, ""
+	}
+	if match[2] == "Half" {
+		return uint32(speed), DuplexHalf
+	}
+	return uint32(speed), DuplexFull
+}
+
+// optionalString returns the value of v, or "any" if v is nil.
+func optionalString[T any](v *T) string {
+	if v == nil {
+		return "any"
+	}
+	return fmt.Sprint(*v)
+}
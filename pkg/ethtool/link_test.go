@@ -0,0 +1,91 @@
+package ethtool
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/pointer"
+)
+
+const (
+	ens3SettingsOutput = `Settings for ens3:
+	Supported ports: [ TP ]
+	Supported link modes:   10baseT/Half 10baseT/Full
+	                        100baseT/Half 100baseT/Full
+	                        1000baseT/Full
+	Supported pause frame use: No
+	Supports auto-negotiation: Yes
+	Supported FEC modes: Not reported
+	Advertised link modes:  100baseT/Full
+	                        1000baseT/Full
+	Advertised pause frame use: No
+	Advertised auto-negotiation: Yes
+	Advertised FEC modes: Not reported
+	Speed: 1000Mb/s
+	Duplex: Full
+	Auto-negotiation: on
+	Port: Twisted Pair
+	PHYAD: 0
+	Transceiver: internal
+	MDI-X: off (auto)
+	Supports Wake-on: umbg
+	Wake-on: d
+	Current message level: 0x00000007 (7)
+			       drv probe link
+	Link detected: yes
+`
+)
+
+func TestExecBackendLinkSettings(t *testing.T) {
+	fake := newFakeCommand(t, []string{"ens3"}, ens3SettingsOutput, "-s", nil)
+
+	backend := execBackend{}
+	params, err := backend.GetLinkSettings("ens3")
+	if err != nil {
+		t.Fatalf("GetLinkSettings(ens3): expected to see no error but got %q", err)
+	}
+	expected := &LinkParameters{
+		Current: Link{
+			Speed:     pointer.Uint32(1000),
+			Duplex:    pointer.String(DuplexFull),
+			Autoneg:   pointer.Bool(true),
+			Advertise: []string{"100baseT/Full", "1000baseT/Full"},
+			MDIX:      pointer.String(MDIXAuto),
+		},
+		Supported: []string{"10baseT/Half", "10baseT/Full", "100baseT/Half", "100baseT/Full", "1000baseT/Full",
+			"Autoneg"},
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("GetLinkSettings(ens3): expected %v but got %v", expected, params)
+	}
+
+	if err := backend.SetLinkSettings("ens3", &Link{Autoneg: pointer.Bool(true),
+		Advertise: []string{"1000baseT/Full"}, MDIX: pointer.String(MDIXOn)}); err != nil {
+		t.Fatalf("SetLinkSettings(ens3): expected to see no error but got %q", err)
+	}
+	expectedParameters := [][]string{{"-s", "ens3", "autoneg", "on", "advertise", "1000baseT/Full", "on",
+		"100baseT/Full", "off", "100baseT/Half", "off", "10baseT/Full", "off", "10baseT/Half", "off", "mdix", "on"}}
+	if !reflect.DeepEqual(fake.setParameters, expectedParameters) {
+		t.Fatalf("SetLinkSettings(ens3): expected parameters %v but got %v", expectedParameters, fake.setParameters)
+	}
+}
+
+func TestValidateLink(t *testing.T) {
+	ethtoolConfigs := EthtoolConfigs{"eth0": {Self: &Settings{Link: &Link{
+		Speed:     pointer.Uint32(0),
+		Duplex:    pointer.String("Full"),
+		Autoneg:   pointer.Bool(false),
+		Advertise: []string{"10000baseT/Full", "Pause"},
+		MDIX:      pointer.String("forced"),
+	}}}}
+	var paths []string
+	for _, e := range ethtoolConfigs.Validate(ConfigVersionV1) {
+		paths = append(paths, e.Path)
+	}
+	expected := []string{"ethtool.eth0.self.link.advertise", "ethtool.eth0.self.link.advertise[1]",
+		"ethtool.eth0.self.link.duplex", "ethtool.eth0.self.link.mdix", "ethtool.eth0.self.link.speed"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Validate(%s): expected errors for %v but got %v", ethtoolConfigs, expected,
+			ethtoolConfigs.Validate(ConfigVersionV1))
+	}
+}
@@ -0,0 +1,85 @@
+package ethtool
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// AllInterfaces is the key of EthtoolConfigs that matches every interface inside the sandbox.
+	AllInterfaces = "*"
+	// regexPrefix marks keys of EthtoolConfigs that are regular expressions, e.g. "re:^net[0-9]+$".
+	regexPrefix = "re:"
+)
+
+// isPattern returns true if key is a pattern rather than an interface name. Interface names cannot contain ':', so
+// regular expressions never collide with them.
+func isPattern(key string) bool {
+	return strings.HasPrefix(key, regexPrefix) || strings.ContainsAny(key, `*?[\`)
+}
+
+// matchKey returns true if pattern key matches interface interfaceName. Keys with prefix "re:" are regular
+// expressions, all other patterns are globs, see path.Match.
+func matchKey(key, interfaceName string) (bool, error) {
+	if expr, ok := strings.CutPrefix(key, regexPrefix); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(interfaceName), nil
+	}
+	return path.Match(key, interfaceName)
+}
+
+// Match returns the configuration of every interface, keyed by interface name. A key of es selects an interface if it
+// is the interface name, a glob such as "net*", a regular expression with prefix "re:" such as "re:^net[0-9]+$" or
+// AllInterfaces. Patterns are matched against interfaceNames, the interfaces inside the sandbox. An exact key takes
+// precedence over patterns and patterns take precedence over AllInterfaces. If more than one pattern matches the same
+// interface, Match fails. Exact keys are always part of the result, even if interfaceNames does not contain them.
+func (es EthtoolConfigs) Match(interfaceNames []string) (EthtoolConfigs, error) {
+	matched := EthtoolConfigs{}
+	var patterns []string
+	for key, ethtoolConfig := range es {
+		if key == AllInterfaces || isPattern(key) {
+			patterns = append(patterns, key)
+			continue
+		}
+		ethtoolConfig.key = key
+		matched[key] = ethtoolConfig
+	}
+	sort.Strings(patterns)
+	for _, interfaceName := range interfaceNames {
+		if _, ok := matched[interfaceName]; ok {
+			continue
+		}
+		var keys []string
+		for _, key := range patterns {
+			if key == AllInterfaces {
+				continue
+			}
+			ok, err := matchKey(key, interfaceName)
+			if err != nil {
+				return nil, fmt.Errorf("invalid interface pattern %q, err: %q", key, err)
+			}
+			if ok {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) > 1 {
+			return nil, fmt.Errorf("interface %s matches more than one interface pattern: %q", interfaceName, keys)
+		}
+		if len(keys) == 0 {
+			if _, ok := es[AllInterfaces]; !ok {
+				continue
+			}
+			keys = append(keys, AllInterfaces)
+		}
+		ethtoolConfig := es[keys[0]]
+		ethtoolConfig.key = keys[0]
+		matched[interfaceName] = ethtoolConfig
+	}
+	return matched, nil
+}
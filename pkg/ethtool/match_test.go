@@ -0,0 +1,66 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	in := `{
+		"eth0": {"self": {"features": {"rx-gro": false}}},
+		"net*": {"self": {"features": {"rx-gro": true}}},
+		"re:^vf[0-9]+$": {"self": {"features": {"tx-checksumming": false}}},
+		"*": {"self": {"features": {"rx-checksumming": false}}}
+	}`
+	var es EthtoolConfigs
+	if err := json.Unmarshal([]byte(in), &es); err != nil {
+		t.Fatalf("Unmarshal: expected to see no error but got %q", err)
+	}
+
+	tcs := []struct {
+		interfaceNames []string
+		expected       map[string]string
+	}{
+		{nil, map[string]string{"eth0": "eth0"}},
+		{[]string{"eth0", "net1", "net10", "vf3", "vf3a", "lo"}, map[string]string{
+			"eth0": "eth0", "net1": "net*", "net10": "net*", "vf3": "re:^vf[0-9]+$", "vf3a": "*", "lo": "*",
+		}},
+	}
+	for _, tc := range tcs {
+		matched, err := es.Match(tc.interfaceNames)
+		if err != nil {
+			t.Fatalf("Match(%v): expected to see no error but got %q", tc.interfaceNames, err)
+		}
+		keys := map[string]string{}
+		for interfaceName, ethtoolConfig := range matched {
+			keys[interfaceName] = ethtoolConfig.key
+			if !reflect.DeepEqual(ethtoolConfig.Self, es[ethtoolConfig.key].Self) {
+				t.Fatalf("Match(%v): expected %s to have the settings of %q but got %v", tc.interfaceNames,
+					interfaceName, ethtoolConfig.key, ethtoolConfig.Self)
+			}
+		}
+		if !reflect.DeepEqual(keys, tc.expected) {
+			t.Fatalf("Match(%v): expected %v but got %v", tc.interfaceNames, tc.expected, keys)
+		}
+	}
+
+	es["re:^net1"] = es["net*"]
+	if _, err := es.Match([]string{"net1"}); err == nil || !strings.Contains(err.Error(), "more than one") {
+		t.Fatalf("Match: expected to see an error for overlapping patterns but got %q", err)
+	}
+}
+
+func TestValidatePatterns(t *testing.T) {
+	in := `{"re:^net(": {"self": {"features": {"rx-gro": false}}}, "net[": {"self": {"features": {"rx-gro": false}}},
+		"net*": {"self": {"features": {"rx-gro": false}}}}`
+	var es EthtoolConfigs
+	if err := json.Unmarshal([]byte(in), &es); err != nil {
+		t.Fatalf("Unmarshal: expected to see no error but got %q", err)
+	}
+	errs := es.Validate(ConfigVersionV1)
+	if len(errs) != 2 || errs[0].Path != "ethtool.net[" || errs[1].Path != "ethtool.re:^net(" {
+		t.Fatalf("Validate(%s): expected errors for both invalid patterns but got %v", in, errs)
+	}
+}
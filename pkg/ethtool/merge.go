@@ -0,0 +1,181 @@
+package ethtool
+
+// Merge returns a copy of es with overrides applied per interface, see EthtoolConfig.Merge. Both es and overrides
+// should be keyed by interface name, see Match.
+func (es EthtoolConfigs) Merge(overrides EthtoolConfigs) EthtoolConfigs {
+	merged := EthtoolConfigs{}
+	for interfaceName, ethtoolConfig := range es {
+		merged[interfaceName] = ethtoolConfig.Merge(EthtoolConfig{})
+	}
+	for interfaceName, override := range overrides {
+		merged[interfaceName] = merged[interfaceName].Merge(override)
+	}
+	return merged
+}
+
+// Merge returns a copy of e with override applied per classifier, see Settings.Merge.
+func (e EthtoolConfig) Merge(override EthtoolConfig) EthtoolConfig {
+	merged := EthtoolConfig{
+		Self:   e.Self.Merge(override.Self),
+		Peer:   e.Peer.Merge(override.Peer),
+		Parent: e.Parent.Merge(override.Parent),
+		key:    e.key,
+	}
+	if merged.key == "" {
+		merged.key = override.key
+	}
+	merged.unknownClassifiers = append(merged.unknownClassifiers, e.unknownClassifiers...)
+	merged.unknownClassifiers = append(merged.unknownClassifiers, override.unknownClassifiers...)
+	return merged
+}
+
+// Merge returns a copy of s in which every setting that override configures is replaced by the value of override.
+// Settings that override does not configure keep the value of s. Merge returns nil if both s and override are nil.
+func (s *Settings) Merge(override *Settings) *Settings {
+	if s == nil && override == nil {
+		return nil
+	}
+	if override == nil {
+		override = &Settings{}
+	}
+	merged := s.Copy()
+	if merged == nil {
+		merged = &Settings{}
+	}
+	if override.Features != nil {
+		if merged.Features == nil {
+			merged.Features = map[string]bool{}
+		}
+		for feature, enable := range override.Features {
+			merged.Features[feature] = enable
+		}
+	}
+	merged.legacyFeatures = append(append([]string{}, s.getLegacyFeatures()...), override.legacyFeatures...)
+	if len(merged.legacyFeatures) == 0 {
+		merged.legacyFeatures = nil
+	}
+	if override.PrivFlags != nil {
+		if merged.PrivFlags == nil {
+			merged.PrivFlags = map[string]bool{}
+		}
+		for name, enable := range override.PrivFlags {
+			merged.PrivFlags[name] = enable
+		}
+	}
+	if override.Rings != nil {
+		if merged.Rings == nil {
+			merged.Rings = &Rings{}
+		}
+		dst := merged.Rings.fields()
+		for name, field := range override.Rings.fields() {
+			if *field != nil {
+				size := **field
+				*dst[name] = &size
+			}
+		}
+	}
+	if override.Channels != nil {
+		if merged.Channels == nil {
+			merged.Channels = &Channels{}
+		}
+		dst := merged.Channels.fields()
+		for name, v := range override.Channels.values() {
+			v := v
+			*dst[name] = &v
+		}
+	}
+	if override.Coalesce != nil {
+		// merge only fills in parameters that are not set yet, so start from the override.
+		coalesce := override.Coalesce.Copy()
+		if merged.Coalesce != nil {
+			coalesce.merge(merged.Coalesce, merged.Coalesce)
+		}
+		merged.Coalesce = coalesce
+	}
+	if override.Pause != nil {
+		pause := override.Pause.Copy()
+		if base := merged.Pause; base != nil {
+			dst := pause.fields()
+			for name, field := range base.fields() {
+				if *dst[name] == nil {
+					*dst[name] = *field
+				}
+			}
+			if pause.PFCAware == nil {
+				pause.PFCAware = base.PFCAware
+			}
+		}
+		merged.Pause = pause
+	}
+	if override.Link != nil {
+		link := override.Link.Copy()
+		if base := merged.Link; base != nil {
+			if link.Speed == nil {
+				link.Speed = base.Speed
+			}
+			if link.Duplex == nil {
+				link.Duplex = base.Duplex
+			}
+			if link.Autoneg == nil {
+				link.Autoneg = base.Autoneg
+			}
+			if len(link.Advertise) == 0 {
+				link.Advertise = base.Advertise
+			}
+			if link.MDIX == nil {
+				link.MDIX = base.MDIX
+			}
+		}
+		merged.Link = link
+	}
+	if override.RSS != nil {
+		rss := override.RSS.Copy()
+		if base := merged.RSS; base != nil {
+			if rss.HFunc == nil {
+				rss.HFunc = base.HFunc
+			}
+			if rss.HKey == nil {
+				rss.HKey = base.HKey
+			}
+			// Equal, Weight and Table are alternatives, so the indirection table is merged as a whole.
+			if !rss.configuresTable() {
+				rss.Equal, rss.Weight, rss.Table = base.Equal, base.Weight, base.Table
+			}
+			if len(rss.Contexts) == 0 {
+				rss.Contexts = base.Contexts
+			}
+		}
+		merged.RSS = rss
+	}
+	if override.VF != nil {
+		vf := override.VF.Copy()
+		if base := merged.VF; base != nil {
+			if vf.SpoofCheck == nil {
+				vf.SpoofCheck = base.SpoofCheck
+			}
+			if vf.Trust == nil {
+				vf.Trust = base.Trust
+			}
+			if vf.MinTxRate == nil {
+				vf.MinTxRate = base.MinTxRate
+			}
+			if vf.MaxTxRate == nil {
+				vf.MaxTxRate = base.MaxTxRate
+			}
+		}
+		merged.VF = vf
+	}
+	// Flow rules are ordered and may overlap, so the rules of override replace the rules of base.
+	if override.FlowRules != nil {
+		merged.FlowRules = override.Copy().FlowRules
+	}
+	return merged
+}
+
+// getLegacyFeatures returns the offloading attributes of s that were listed outside of section "features".
+func (s *Settings) getLegacyFeatures() []string {
+	if s == nil {
+		return nil
+	}
+	return s.legacyFeatures
+}
@@ -0,0 +1,71 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/pointer"
+)
+
+func TestMerge(t *testing.T) {
+	base := `{
+		"eth0": {"self": {"features": {"rx-gro": false, "tx-checksumming": false}, "rings": {"rx": 512, "tx": 512},
+			"coalesce": {"rx-usecs": 8, "adaptive-rx": false}}, "peer": {"features": {"rx-gro": false}}},
+		"net1": {"self": {"features": {"rx-gro": false}}}
+	}`
+	overrides := `{
+		"eth0": {"self": {"features": {"rx-gro": true}, "rings": {"rx": "max"}, "channels": {"combined": 2},
+			"coalesce": {"adaptive-rx": true}}},
+		"net2": {"self": {"features": {"rx-gro": true}}}
+	}`
+	var b, o EthtoolConfigs
+	if err := json.Unmarshal([]byte(base), &b); err != nil {
+		t.Fatalf("Unmarshal: expected to see no error but got %q", err)
+	}
+	if err := json.Unmarshal([]byte(overrides), &o); err != nil {
+		t.Fatalf("Unmarshal: expected to see no error but got %q", err)
+	}
+	merged := b.Merge(o)
+
+	expected := EthtoolConfigs{
+		"eth0": {
+			Self: &Settings{
+				Features: map[string]bool{"rx-gro": true, "tx-checksumming": false},
+				Rings:    &Rings{RX: &RingSize{Max: true}, TX: &RingSize{Value: 512}},
+				Channels: &Channels{Combined: pointer.Uint32(2)},
+				Coalesce: &Coalesce{RXUsecs: pointer.Uint32(8), AdaptiveRX: pointer.Bool(true)},
+			},
+			Peer: &Settings{Features: map[string]bool{"rx-gro": false}},
+		},
+		"net1": {Self: &Settings{Features: map[string]bool{"rx-gro": false}}},
+		"net2": {Self: &Settings{Features: map[string]bool{"rx-gro": true}}},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Fatalf("Merge: expected %v but got %v", expected, merged)
+	}
+	// Merge must not modify its inputs.
+	if b["eth0"].Self.Features["rx-gro"] || b["eth0"].Self.Channels != nil {
+		t.Fatalf("Merge: expected the base configuration to be unchanged but got %v", b)
+	}
+}
+
+func TestValidateOverrides(t *testing.T) {
+	in := `{"eth0": {"peer": {"features": {"rx-gro": false}}}, "eth1": {"peer": {"rings": {}}}}`
+	var es EthtoolConfigs
+	if err := json.Unmarshal([]byte(in), &es); err != nil {
+		t.Fatalf("Unmarshal: expected to see no error but got %q", err)
+	}
+	expected := ValidationErrors{{
+		Path:  "runtimeConfig.ethtool.eth1.peer",
+		Value: `{"rings":{}}`,
+		Rule:  "must configure at least one setting",
+	}, {
+		Path:  "runtimeConfig.ethtool.eth1.peer.rings",
+		Value: "{}",
+		Rule:  "section must not be empty",
+	}}
+	if errs := es.ValidateOverrides("runtimeConfig.ethtool", ConfigVersionV1); !reflect.DeepEqual(errs, expected) {
+		t.Fatalf("ValidateOverrides(%s): expected %v but got %v", in, expected, errs)
+	}
+}
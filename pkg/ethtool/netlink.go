@@ -0,0 +1,970 @@
+package ethtool
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// Constants of the ethtool generic netlink family, see include/uapi/linux/ethtool_netlink.h.
+const (
+	ethtoolGenlName    = "ethtool"
+	ethtoolGenlVersion = 1
+
+	ethtoolMsgStrsetGet    = 1
+	ethtoolMsgLinkinfoGet  = 2
+	ethtoolMsgLinkinfoSet  = 3
+	ethtoolMsgLinkmodesGet = 4
+	ethtoolMsgLinkmodesSet = 5
+	ethtoolMsgFeaturesGet  = 11
+	ethtoolMsgFeaturesSet  = 12
+	ethtoolMsgPrivflagsGet = 13
+	ethtoolMsgPrivflagsSet = 14
+	ethtoolMsgRingsGet     = 15
+	ethtoolMsgRingsSet     = 16
+	ethtoolMsgChannelsGet  = 17
+	ethtoolMsgChannelsSet  = 18
+	ethtoolMsgCoalesceGet  = 19
+	ethtoolMsgCoalesceSet  = 20
+	ethtoolMsgPauseGet     = 21
+	ethtoolMsgPauseSet     = 22
+
+	// All request and reply messages carry the header nest as attribute 1.
+	ethtoolAHeader        = 1
+	ethtoolAHeaderDevName = 2
+	ethtoolAHeaderFlags   = 3
+
+	ethtoolFlagCompactBitsets = 1 << 0
+	ethtoolFlagOmitReply      = 1 << 1
+
+	ethtoolABitsetNomask   = 1
+	ethtoolABitsetSize     = 2
+	ethtoolABitsetBits     = 3
+	ethtoolABitsetValue    = 4
+	ethtoolABitsetMask     = 5
+	ethtoolABitsetBitsBit  = 1
+	ethtoolABitsetBitIndex = 1
+	ethtoolABitsetBitName  = 2
+	ethtoolABitsetBitValue = 3
+
+	ethtoolAStrsetStringsets    = 2
+	ethtoolAStringsetsStringset = 1
+	ethtoolAStringsetID         = 1
+	ethtoolAStringsetCount      = 2
+	ethtoolAStringsetStrings    = 3
+	ethtoolAStringsString       = 1
+	ethtoolAStringIndex         = 1
+	ethtoolAStringValue         = 2
+
+	ethtoolAFeaturesHW       = 2
+	ethtoolAFeaturesWanted   = 3
+	ethtoolAFeaturesActive   = 4
+	ethtoolAFeaturesNochange = 5
+
+	ethtoolAPrivflagsFlags = 2
+
+	ethtoolARingsRXMax      = 2
+	ethtoolARingsRXMiniMax  = 3
+	ethtoolARingsRXJumboMax = 4
+	ethtoolARingsTXMax      = 5
+	ethtoolARingsRX         = 6
+	ethtoolARingsRXMini     = 7
+	ethtoolARingsRXJumbo    = 8
+	ethtoolARingsTX         = 9
+	ethtoolARingsRXBufLen   = 10
+	ethtoolARingsCQESize    = 12
+
+	ethtoolAChannelsRXMax         = 2
+	ethtoolAChannelsTXMax         = 3
+	ethtoolAChannelsOtherMax      = 4
+	ethtoolAChannelsCombinedMax   = 5
+	ethtoolAChannelsRXCount       = 6
+	ethtoolAChannelsTXCount       = 7
+	ethtoolAChannelsOtherCount    = 8
+	ethtoolAChannelsCombinedCount = 9
+
+	ethtoolACoalesceRXUsecs       = 2
+	ethtoolACoalesceRXMaxFrames   = 3
+	ethtoolACoalesceTXUsecs       = 6
+	ethtoolACoalesceTXMaxFrames   = 7
+	ethtoolACoalesceUseAdaptiveRX = 11
+	ethtoolACoalesceUseAdaptiveTX = 12
+	ethtoolACoalesceUseCQEModeTX  = 24
+	ethtoolACoalesceUseCQEModeRX  = 25
+
+	ethtoolALinkinfoTPMDIXCtrl = 5
+
+	ethtoolALinkmodesAutoneg = 2
+	ethtoolALinkmodesOurs    = 3
+	ethtoolALinkmodesSpeed   = 5
+	ethtoolALinkmodesDuplex  = 6
+
+	ethtoolAPauseAutoneg = 2
+	ethtoolAPauseRX      = 3
+	ethtoolAPauseTX      = 4
+
+	ethSSPrivFlags    = 2
+	ethSSFeatures     = 4
+	ethSSRSSHashFuncs = 5
+	ethSSLinkModes    = 9
+
+	speedUnknown  = 0xffffffff
+	duplexUnknown = 0xff
+)
+
+// netlinkBackend talks to the kernel through the ethtool generic netlink family (ETHTOOL_GENL). It operates on the
+// network namespace of the calling thread and does not need any external binaries.
+type netlinkBackend struct {
+	familyID uint16
+}
+
+func newNetlinkBackend() (*netlinkBackend, error) {
+	family, err := netlink.GenlFamilyGet(ethtoolGenlName)
+	if err != nil {
+		return nil, fmt.Errorf("could not find generic netlink family %q, err: %q", ethtoolGenlName, err)
+	}
+	// Annotate errors with the extended ACK message of the kernel, e.g. "no device matches name".
+	nl.EnableErrorMessageReporting = true
+	return &netlinkBackend{familyID: family.ID}, nil
+}
+
+// ListFeatures implements Backend.
+func (n *netlinkBackend) ListFeatures(iface string) (OffloadList, error) {
+	f, err := n.getFeatures(iface)
+	if err != nil {
+		return nil, err
+	}
+	return f.offloadList(), nil
+}
+
+// FeatureNames implements Backend.
+func (n *netlinkBackend) FeatureNames(iface string) ([]string, error) {
+	return n.stringSet(iface, ethSSFeatures)
+}
+
+// SetFeatures implements Backend. Long names such as "tx-checksumming" are expanded to the kernel features that they
+// stand for, just like the ethtool binary does.
+func (n *netlinkBackend) SetFeatures(iface string, features map[string]bool) error {
+	names, err := n.stringSet(iface, ethSSFeatures)
+	if err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, name := range names {
+		known[name] = true
+	}
+
+	bits := nested(ethtoolABitsetBits)
+	for feature, enable := range features {
+		kernelNames := []string{feature}
+		if lf, ok := findLegacyFeature(feature); ok {
+			kernelNames = nil
+			for _, kernelName := range lf.kernelNames {
+				if known[kernelName] {
+					kernelNames = append(kernelNames, kernelName)
+				}
+			}
+		}
+		if len(kernelNames) == 0 || (len(kernelNames) == 1 && !known[kernelNames[0]]) {
+			return fmt.Errorf("interface %q has no offloading attribute %q", iface, feature)
+		}
+		for _, kernelName := range kernelNames {
+			bit := nested(ethtoolABitsetBitsBit)
+			bit.AddRtAttr(ethtoolABitsetBitName, nl.ZeroTerminated(kernelName))
+			if enable {
+				bit.AddRtAttr(ethtoolABitsetBitValue, nil)
+			}
+			bits.AddChild(bit)
+		}
+	}
+	wanted := nested(ethtoolAFeaturesWanted)
+	wanted.AddChild(bits)
+
+	if _, err := n.request(ethtoolMsgFeaturesSet, unix.NLM_F_ACK, header(iface, ethtoolFlagOmitReply),
+		wanted); err != nil {
+		return fmt.Errorf("could not set features %v of interface %q, err: %q", features, iface, err)
+	}
+	return nil
+}
+
+// GetPrivFlags implements Backend.
+func (n *netlinkBackend) GetPrivFlags(iface string) (map[string]bool, error) {
+	names, err := n.stringSet(iface, ethSSPrivFlags)
+	if err != nil {
+		return nil, err
+	}
+	privFlags := map[string]bool{}
+	if len(names) == 0 {
+		return privFlags, nil
+	}
+	msgs, err := n.request(ethtoolMsgPrivflagsGet, 0, header(iface, ethtoolFlagCompactBitsets))
+	if err != nil {
+		return nil, fmt.Errorf("could not get private flags of interface %q, err: %q", iface, err)
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("unexpected number of replies when getting private flags of interface %q", iface)
+	}
+	attrs, err := parseAttributes(msgs[0])
+	if err != nil {
+		return nil, err
+	}
+	flags, err := parseCompactBitset(attrs.get(ethtoolAPrivflagsFlags))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse private flags of interface %q, err: %q", iface, err)
+	}
+	for i, name := range names {
+		privFlags[name] = flags.isSet(i)
+	}
+	return privFlags, nil
+}
+
+// SetPrivFlags implements Backend.
+func (n *netlinkBackend) SetPrivFlags(iface string, privFlags map[string]bool) error {
+	if len(privFlags) == 0 {
+		return nil
+	}
+	bits := nested(ethtoolABitsetBits)
+	for _, name := range privFlagNames(privFlags) {
+		bit := nested(ethtoolABitsetBitsBit)
+		bit.AddRtAttr(ethtoolABitsetBitName, nl.ZeroTerminated(name))
+		if privFlags[name] {
+			bit.AddRtAttr(ethtoolABitsetBitValue, nil)
+		}
+		bits.AddChild(bit)
+	}
+	flags := nested(ethtoolAPrivflagsFlags)
+	flags.AddChild(bits)
+	if err := n.set(ethtoolMsgPrivflagsSet, iface, flags); err != nil {
+		return fmt.Errorf("could not set private flags %v of interface %q, err: %q", privFlags, iface, err)
+	}
+	return nil
+}
+
+// GetRings implements Backend.
+func (n *netlinkBackend) GetRings(iface string) (*RingParameters, error) {
+	attrs, err := n.get(ethtoolMsgRingsGet, iface)
+	if err != nil {
+		return nil, fmt.Errorf("could not get ring parameters of interface %q, err: %q", iface, err)
+	}
+	rings := &RingParameters{Current: map[string]uint32{}, Max: map[string]uint32{}}
+	for _, field := range ringFields {
+		if v, ok := attrs.lookupUint32(field.attr); ok {
+			rings.Current[field.name] = v
+		}
+		if field.maxAttr == 0 {
+			continue
+		}
+		if v, ok := attrs.lookupUint32(field.maxAttr); ok {
+			rings.Max[field.name] = v
+		}
+	}
+	return rings, nil
+}
+
+// SetRings implements Backend.
+func (n *netlinkBackend) SetRings(iface string, rings map[string]uint32) error {
+	if len(rings) == 0 {
+		return nil
+	}
+	var attrs []*nl.RtAttr
+	for _, field := range ringFields {
+		if v, ok := rings[field.name]; ok {
+			attrs = append(attrs, nl.NewRtAttr(int(field.attr), nl.Uint32Attr(v)))
+		}
+	}
+	if err := n.set(ethtoolMsgRingsSet, iface, attrs...); err != nil {
+		return fmt.Errorf("could not set ring parameters %v of interface %q, err: %q", rings, iface, err)
+	}
+	return nil
+}
+
+// GetChannels implements Backend.
+func (n *netlinkBackend) GetChannels(iface string) (*ChannelParameters, error) {
+	attrs, err := n.get(ethtoolMsgChannelsGet, iface)
+	if err != nil {
+		return nil, fmt.Errorf("could not get channel parameters of interface %q, err: %q", iface, err)
+	}
+	channels := &ChannelParameters{Current: map[string]uint32{}, Max: map[string]uint32{}}
+	for _, field := range channelFields {
+		if v, ok := attrs.lookupUint32(field.attr); ok {
+			channels.Current[field.name] = v
+		}
+		if v, ok := attrs.lookupUint32(field.maxAttr); ok {
+			channels.Max[field.name] = v
+		}
+	}
+	return channels, nil
+}
+
+// SetChannels implements Backend.
+func (n *netlinkBackend) SetChannels(iface string, channels map[string]uint32) error {
+	if len(channels) == 0 {
+		return nil
+	}
+	var attrs []*nl.RtAttr
+	for _, field := range channelFields {
+		if v, ok := channels[field.name]; ok {
+			attrs = append(attrs, nl.NewRtAttr(int(field.attr), nl.Uint32Attr(v)))
+		}
+	}
+	if err := n.set(ethtoolMsgChannelsSet, iface, attrs...); err != nil {
+		return fmt.Errorf("could not set channel parameters %v of interface %q, err: %q", channels, iface, err)
+	}
+	return nil
+}
+
+// GetCoalesce implements Backend. The kernel only reports the parameters that the driver supports.
+func (n *netlinkBackend) GetCoalesce(iface string) (*Coalesce, error) {
+	attrs, err := n.get(ethtoolMsgCoalesceGet, iface)
+	if err != nil {
+		return nil, fmt.Errorf("could not get coalescing parameters of interface %q, err: %q", iface, err)
+	}
+	coalesce := &Coalesce{}
+	numbers := coalesce.numbers()
+	for _, field := range coalesceFields {
+		if v, ok := attrs.lookupUint32(field.attr); ok {
+			*numbers[field.name] = &v
+		}
+	}
+	flags := coalesce.flags()
+	for _, field := range coalesceFlags {
+		if v, ok := attrs.lookupUint8(field.attr); ok {
+			enabled := v != 0
+			*flags[field.name] = &enabled
+		}
+	}
+	return coalesce, nil
+}
+
+// SetCoalesce implements Backend.
+func (n *netlinkBackend) SetCoalesce(iface string, coalesce *Coalesce) error {
+	if coalesce.IsEmpty() {
+		return nil
+	}
+	var attrs []*nl.RtAttr
+	numbers := coalesce.numbers()
+	for _, field := range coalesceFields {
+		if v := *numbers[field.name]; v != nil {
+			attrs = append(attrs, nl.NewRtAttr(int(field.attr), nl.Uint32Attr(*v)))
+		}
+	}
+	flags := coalesce.flags()
+	for _, field := range coalesceFlags {
+		if v := *flags[field.name]; v != nil {
+			var enabled uint8
+			if *v {
+				enabled = 1
+			}
+			attrs = append(attrs, nl.NewRtAttr(int(field.attr), nl.Uint8Attr(enabled)))
+		}
+	}
+	if err := n.set(ethtoolMsgCoalesceSet, iface, attrs...); err != nil {
+		return fmt.Errorf("could not set coalescing parameters %s of interface %q, err: %q", coalesce, iface, err)
+	}
+	return nil
+}
+
+// GetPause implements Backend.
+func (n *netlinkBackend) GetPause(iface string) (*Pause, error) {
+	attrs, err := n.get(ethtoolMsgPauseGet, iface)
+	if err != nil {
+		return nil, fmt.Errorf("could not get pause parameters of interface %q, err: %q", iface, err)
+	}
+	pause := &Pause{}
+	fields := pause.fields()
+	for _, field := range pauseFields {
+		if v, ok := attrs.lookupUint8(field.attr); ok {
+			enabled := v != 0
+			*fields[field.name] = &enabled
+		}
+	}
+	return pause, nil
+}
+
+// SetPause implements Backend.
+func (n *netlinkBackend) SetPause(iface string, pause *Pause) error {
+	if pause.IsEmpty() {
+		return nil
+	}
+	var attrs []*nl.RtAttr
+	fields := pause.fields()
+	for _, field := range pauseFields {
+		if v := *fields[field.name]; v != nil {
+			var enabled uint8
+			if *v {
+				enabled = 1
+			}
+			attrs = append(attrs, nl.NewRtAttr(int(field.attr), nl.Uint8Attr(enabled)))
+		}
+	}
+	if err := n.set(ethtoolMsgPauseSet, iface, attrs...); err != nil {
+		return fmt.Errorf("could not set pause parameters %s of interface %q, err: %q", pause, iface, err)
+	}
+	return nil
+}
+
+// PFCEnabled implements Backend.
+func (n *netlinkBackend) PFCEnabled(iface string) (bool, error) {
+	return pfcEnabled(iface)
+}
+
+// GetLinkSettings implements Backend.
+func (n *netlinkBackend) GetLinkSettings(iface string) (*LinkParameters, error) {
+	names, err := n.stringSet(iface, ethSSLinkModes)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := n.request(ethtoolMsgLinkmodesGet, 0, header(iface, ethtoolFlagCompactBitsets))
+	if err != nil {
+		return nil, fmt.Errorf("could not get link modes of interface %q, err: %q", iface, err)
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("unexpected number of replies when getting link modes of interface %q", iface)
+	}
+	attrs, err := parseAttributes(msgs[0])
+	if err != nil {
+		return nil, err
+	}
+	params := &LinkParameters{}
+	if v, ok := attrs.lookupUint8(ethtoolALinkmodesAutoneg); ok {
+		autoneg := v != 0
+		params.Current.Autoneg = &autoneg
+	}
+	if v, ok := attrs.lookupUint32(ethtoolALinkmodesSpeed); ok && v != 0 && v != speedUnknown {
+		params.Current.Speed = &v
+	}
+	if v, ok := attrs.lookupUint8(ethtoolALinkmodesDuplex); ok && v != duplexUnknown {
+		for name, value := range duplexValues {
+			if value == v {
+				duplex := name
+				params.Current.Duplex = &duplex
+			}
+		}
+	}
+	if ours := attrs.get(ethtoolALinkmodesOurs); ours != nil {
+		advertised, supported, err := parseCompactBitsetWithMask(ours)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse link modes of interface %q, err: %q", iface, err)
+		}
+		for i, name := range names {
+			if advertised.isSet(i) {
+				params.Current.Advertise = append(params.Current.Advertise, name)
+			}
+			if supported.isSet(i) {
+				params.Supported = append(params.Supported, name)
+			}
+		}
+	}
+
+	attrs, err = n.get(ethtoolMsgLinkinfoGet, iface)
+	if err != nil {
+		return nil, fmt.Errorf("could not get link info of interface %q, err: %q", iface, err)
+	}
+	if v, ok := attrs.lookupUint8(ethtoolALinkinfoTPMDIXCtrl); ok {
+		for name, value := range mdixValues {
+			if value == v {
+				mdix := name
+				params.Current.MDIX = &mdix
+			}
+		}
+	}
+	return params, nil
+}
+
+// SetLinkSettings implements Backend. Advertised link modes are changed with a bitset that lists every speed and duplex
+// link mode of the device, so that other capabilities such as "Pause" are not changed.
+func (n *netlinkBackend) SetLinkSettings(iface string, link *Link) error {
+	if link.IsEmpty() {
+		return nil
+	}
+	if link.configuresLinkModes() {
+		var attrs []*nl.RtAttr
+		if link.Autoneg != nil {
+			var enabled uint8
+			if *link.Autoneg {
+				enabled = 1
+			}
+			attrs = append(attrs, nl.NewRtAttr(ethtoolALinkmodesAutoneg, nl.Uint8Attr(enabled)))
+		}
+		if link.Speed != nil {
+			attrs = append(attrs, nl.NewRtAttr(ethtoolALinkmodesSpeed, nl.Uint32Attr(*link.Speed)))
+		}
+		if link.Duplex != nil {
+			attrs = append(attrs, nl.NewRtAttr(ethtoolALinkmodesDuplex, nl.Uint8Attr(duplexValues[*link.Duplex])))
+		}
+		if len(link.Advertise) > 0 {
+			params, err := n.GetLinkSettings(iface)
+			if err != nil {
+				return err
+			}
+			bits := nested(ethtoolABitsetBits)
+			for _, mode := range speedModes(params.Supported) {
+				bit := nested(ethtoolABitsetBitsBit)
+				bit.AddRtAttr(ethtoolABitsetBitName, nl.ZeroTerminated(mode))
+				if slices.Contains(link.Advertise, mode) {
+					bit.AddRtAttr(ethtoolABitsetBitValue, nil)
+				}
+				bits.AddChild(bit)
+			}
+			ours := nested(ethtoolALinkmodesOurs)
+			ours.AddChild(bits)
+			attrs = append(attrs, ours)
+		}
+		if err := n.set(ethtoolMsgLinkmodesSet, iface, attrs...); err != nil {
+			return fmt.Errorf("could not set link modes %s of interface %q, err: %q", link, iface, err)
+		}
+	}
+	if link.MDIX != nil {
+		if err := n.set(ethtoolMsgLinkinfoSet, iface,
+			nl.NewRtAttr(ethtoolALinkinfoTPMDIXCtrl, nl.Uint8Attr(mdixValues[*link.MDIX]))); err != nil {
+			return fmt.Errorf("could not set MDI-X %s of interface %q, err: %q", *link.MDIX, iface, err)
+		}
+	}
+	return nil
+}
+
+// GetRSS implements Backend. RSS settings are read and changed through the ethtool ioctl interface, because only
+// recent kernels can change them through the ethtool generic netlink family.
+func (n *netlinkBackend) GetRSS(iface string, context uint32) (*RSSParameters, error) {
+	hfuncs, err := n.stringSet(iface, ethSSRSSHashFuncs)
+	if err != nil {
+		return nil, err
+	}
+	rxRings, err := getRXRings(iface)
+	if err != nil {
+		return nil, fmt.Errorf("could not get RX rings of interface %q, err: %q", iface, err)
+	}
+	r, err := getRXFH(iface, context)
+	if err != nil {
+		return nil, fmt.Errorf("could not get RSS context %d of interface %q, err: %q", context, iface, err)
+	}
+	params := &RSSParameters{HFuncs: hfuncs, RXRings: rxRings, Current: RSSContext{Table: r.indir}}
+	for i, name := range hfuncs {
+		if r.hfunc&(1<<i) != 0 {
+			hfunc := name
+			params.Current.HFunc = &hfunc
+		}
+	}
+	if len(r.key) > 0 {
+		hkey := formatHKey(r.key)
+		params.Current.HKey = &hkey
+	}
+	return params, nil
+}
+
+// SetRSS implements Backend.
+func (n *netlinkBackend) SetRSS(iface string, context uint32, rss *RSSContext) error {
+	if _, err := n.applyRSS(iface, context, rss); err != nil {
+		return fmt.Errorf("could not set RSS context %d of interface %q to %s, err: %q", context, iface,
+			RSS{RSSContext: *rss}, err)
+	}
+	return nil
+}
+
+// ResetRSSTable implements Backend. An empty indirection table resets the table of the default context.
+func (n *netlinkBackend) ResetRSSTable(iface string) error {
+	if _, err := setRXFH(iface, &rxfh{}); err != nil {
+		return fmt.Errorf("could not reset the indirection table of interface %q, err: %q", iface, err)
+	}
+	return nil
+}
+
+// CreateRSSContext implements Backend.
+func (n *netlinkBackend) CreateRSSContext(iface string, rss *RSSContext) (uint32, error) {
+	id, err := n.applyRSS(iface, ethRXFHContextAlloc, rss)
+	if err != nil {
+		return 0, fmt.Errorf("could not create RSS context %s on interface %q, err: %q", RSS{RSSContext: *rss},
+			iface, err)
+	}
+	return id, nil
+}
+
+// DeleteRSSContext implements Backend. An empty indirection table deletes a context other than the default context.
+// Like for flow rules, the kernel reports ENOENT for a context that does not exist.
+func (n *netlinkBackend) DeleteRSSContext(iface string, context uint32) error {
+	if _, err := setRXFH(iface, &rxfh{context: context}); err != nil && !errors.Is(err, unix.ENOENT) {
+		return fmt.Errorf("could not delete RSS context %d of interface %q, err: %q", context, iface, err)
+	}
+	return nil
+}
+
+// applyRSS changes the settings of RSS context context of iface that are set in rss. Equal and Weight are resolved to
+// an indirection table of the size of the device.
+func (n *netlinkBackend) applyRSS(iface string, context uint32, rss *RSSContext) (uint32, error) {
+	current, err := getRXFH(iface, 0)
+	if err != nil {
+		return 0, err
+	}
+	r := &rxfh{context: context, indirSize: ethRXFHIndirNoChange}
+	if rss.configuresTable() {
+		r.indir = rss.indirectionTable(len(current.indir))
+		r.indirSize = uint32(len(r.indir))
+	}
+	if rss.HKey != nil {
+		if r.key, err = parseHKey(*rss.HKey); err != nil {
+			return 0, err
+		}
+		r.keySize = uint32(len(r.key))
+	}
+	if rss.HFunc != nil {
+		hfuncs, err := n.stringSet(iface, ethSSRSSHashFuncs)
+		if err != nil {
+			return 0, err
+		}
+		i := slices.Index(hfuncs, *rss.HFunc)
+		if i < 0 {
+			return 0, fmt.Errorf("unknown hash function %q, supported hash functions are %q", *rss.HFunc, hfuncs)
+		}
+		r.hfunc = 1 << i
+	}
+	return setRXFH(iface, r)
+}
+
+// GetFlowRule implements Backend. Flow rules are read and changed through the ethtool ioctl interface, the ethtool
+// generic netlink family does not support them. Drivers report an empty location with ENOENT or, e.g. ixgbe and i40e,
+// with EINVAL.
+func (n *netlinkBackend) GetFlowRule(iface string, loc uint32) (*FlowRule, error) {
+	rule, err := getFlowRule(iface, loc)
+	if errors.Is(err, unix.ENOENT) || errors.Is(err, unix.EINVAL) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get flow rule %d of interface %q, err: %q", loc, iface, err)
+	}
+	return rule, nil
+}
+
+// InsertFlowRule implements Backend.
+func (n *netlinkBackend) InsertFlowRule(iface string, rule *FlowRule) (uint32, error) {
+	loc, err := insertFlowRule(iface, rule)
+	if err != nil {
+		return 0, fmt.Errorf("could not insert flow rule %s on interface %q, err: %q", rule, iface, err)
+	}
+	return loc, nil
+}
+
+// DeleteFlowRule implements Backend.
+func (n *netlinkBackend) DeleteFlowRule(iface string, loc uint32) error {
+	if err := deleteFlowRule(iface, loc); err != nil && !errors.Is(err, unix.ENOENT) {
+		return fmt.Errorf("could not delete flow rule %d of interface %q, err: %q", loc, iface, err)
+	}
+	return nil
+}
+
+// netlinkFeatures holds the feature bitsets of an interface as reported by ETHTOOL_MSG_FEATURES_GET. Bit i of each
+// bitset belongs to names[i].
+type netlinkFeatures struct {
+	names    []string
+	hw       bitset
+	wanted   bitset
+	active   bitset
+	nochange bitset
+}
+
+func (n *netlinkBackend) getFeatures(iface string) (*netlinkFeatures, error) {
+	names, err := n.stringSet(iface, ethSSFeatures)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := n.request(ethtoolMsgFeaturesGet, 0, header(iface, ethtoolFlagCompactBitsets))
+	if err != nil {
+		return nil, fmt.Errorf("could not get features of interface %q, err: %q", iface, err)
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("unexpected number of replies when getting features of interface %q", iface)
+	}
+	attrs, err := parseAttributes(msgs[0])
+	if err != nil {
+		return nil, err
+	}
+	f := &netlinkFeatures{names: names}
+	for attrType, target := range map[uint16]*bitset{
+		ethtoolAFeaturesHW:       &f.hw,
+		ethtoolAFeaturesWanted:   &f.wanted,
+		ethtoolAFeaturesActive:   &f.active,
+		ethtoolAFeaturesNochange: &f.nochange,
+	} {
+		if *target, err = parseCompactBitset(attrs.get(attrType)); err != nil {
+			return nil, fmt.Errorf("could not parse features of interface %q, err: %q", iface, err)
+		}
+	}
+	return f, nil
+}
+
+// offloadList returns the features in the same shape as the output of ethtool --json -k: A long name that stands
+// for a single kernel feature replaces the kernel name. A long name that stands for several kernel features is active
+// if any of them is active, has no fixed and requested state and is listed in addition to the kernel features.
+func (f *netlinkFeatures) offloadList() OffloadList {
+	index := map[string]int{}
+	for i, name := range f.names {
+		if name != "" {
+			index[name] = i
+		}
+	}
+	offloadList := OffloadList{}
+	for _, lf := range legacyFeatures {
+		var members []int
+		for _, kernelName := range lf.kernelNames {
+			if i, ok := index[kernelName]; ok {
+				members = append(members, i)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		if len(members) == 1 {
+			offloadList[lf.name] = f.offload(members[0])
+			delete(index, f.names[members[0]])
+			continue
+		}
+		active := false
+		for _, i := range members {
+			active = active || f.active.isSet(i)
+		}
+		offloadList[lf.name] = Offload{Active: &active}
+	}
+	for _, i := range index {
+		offloadList[f.names[i]] = f.offload(i)
+	}
+	return offloadList
+}
+
+// offload returns the state of the kernel feature at index i. A feature is fixed if the driver does not allow to
+// change it or if it is one of the features that must never change.
+func (f *netlinkFeatures) offload(i int) Offload {
+	active := f.active.isSet(i)
+	fixed := !f.hw.isSet(i) || f.nochange.isSet(i)
+	requested := f.wanted.isSet(i)
+	return Offload{Active: &active, Fixed: &fixed, Requested: &requested}
+}
+
+// stringSet returns the strings of string set id of interface iface, ordered by their index. The kernel omits string
+// sets without strings, e.g. the private flags of most virtual devices, so a missing set is returned as empty.
+func (n *netlinkBackend) stringSet(iface string, id uint32) ([]string, error) {
+	stringsets := nested(ethtoolAStrsetStringsets)
+	stringset := nested(ethtoolAStringsetsStringset)
+	stringset.AddRtAttr(ethtoolAStringsetID, nl.Uint32Attr(id))
+	stringsets.AddChild(stringset)
+
+	msgs, err := n.request(ethtoolMsgStrsetGet, 0, header(iface, 0), stringsets)
+	if err != nil {
+		return nil, fmt.Errorf("could not get string set %d of interface %q, err: %q", id, iface, err)
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("unexpected number of replies when getting string set %d of interface %q", id, iface)
+	}
+	attrs, err := parseAttributes(msgs[0])
+	if err != nil {
+		return nil, err
+	}
+	stringsetsAttrs, err := parseAttributes(attrs.get(ethtoolAStrsetStringsets))
+	if err != nil {
+		return nil, err
+	}
+	for _, stringsetAttr := range stringsetsAttrs.all(ethtoolAStringsetsStringset) {
+		stringsetAttrs, err := parseAttributes(stringsetAttr)
+		if err != nil {
+			return nil, err
+		}
+		if stringsetAttrs.uint32(ethtoolAStringsetID) != id {
+			continue
+		}
+		result := make([]string, stringsetAttrs.uint32(ethtoolAStringsetCount))
+		stringsAttrs, err := parseAttributes(stringsetAttrs.get(ethtoolAStringsetStrings))
+		if err != nil {
+			return nil, err
+		}
+		for _, stringAttr := range stringsAttrs.all(ethtoolAStringsString) {
+			s, err := parseAttributes(stringAttr)
+			if err != nil {
+				return nil, err
+			}
+			i := int(s.uint32(ethtoolAStringIndex))
+			if i >= len(result) {
+				return nil, fmt.Errorf("string index %d of string set %d is out of range", i, id)
+			}
+			result[i] = nl.BytesToString(s.get(ethtoolAStringValue))
+		}
+		return result, nil
+	}
+	return nil, nil
+}
+
+// get sends a request with command cmd for interface iface and returns the attributes of the single reply.
+func (n *netlinkBackend) get(cmd uint8, iface string) (attributes, error) {
+	msgs, err := n.request(cmd, 0, header(iface, 0))
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("unexpected number of replies for command %d", cmd)
+	}
+	return parseAttributes(msgs[0])
+}
+
+// set sends a request with command cmd and the provided attributes for interface iface and waits for the
+// acknowledgement.
+func (n *netlinkBackend) set(cmd uint8, iface string, attrs ...*nl.RtAttr) error {
+	_, err := n.request(cmd, unix.NLM_F_ACK, append([]*nl.RtAttr{header(iface, 0)}, attrs...)...)
+	return err
+}
+
+// request sends an ethtool netlink message with the provided command and attributes and returns the attributes of
+// each reply, without the generic netlink header.
+func (n *netlinkBackend) request(cmd uint8, flags int, attrs ...*nl.RtAttr) ([][]byte, error) {
+	req := nl.NewNetlinkRequest(int(n.familyID), flags)
+	req.AddData(&nl.Genlmsg{Command: cmd, Version: ethtoolGenlVersion})
+	for _, attr := range attrs {
+		req.AddData(attr)
+	}
+	msgs, err := executeRequest(req)
+	if err != nil {
+		return nil, netlinkError{err}
+	}
+	var replies [][]byte
+	for _, msg := range msgs {
+		if len(msg) < nl.SizeofGenlmsg {
+			return nil, fmt.Errorf("received truncated generic netlink message")
+		}
+		replies = append(replies, msg[nl.SizeofGenlmsg:])
+	}
+	return replies, nil
+}
+
+// executeRequest sends req to the kernel and returns the replies, including their generic netlink header.
+var executeRequest = func(req *nl.NetlinkRequest) ([][]byte, error) {
+	return req.Execute(unix.NETLINK_GENERIC, 0)
+}
+
+// netlinkError wraps the errors of the netlink library, whose messages end with the NUL terminator of the kernel's
+// extended ACK message.
+type netlinkError struct {
+	err error
+}
+
+func (e netlinkError) Error() string {
+	return strings.TrimRight(e.err.Error(), "\x00")
+}
+
+func (e netlinkError) Unwrap() error {
+	return e.err
+}
+
+// header returns the request header nest for interface iface.
+func header(iface string, flags uint32) *nl.RtAttr {
+	h := nested(ethtoolAHeader)
+	h.AddRtAttr(ethtoolAHeaderDevName, nl.ZeroTerminated(iface))
+	if flags != 0 {
+		h.AddRtAttr(ethtoolAHeaderFlags, nl.Uint32Attr(flags))
+	}
+	return h
+}
+
+// nested returns an empty nested attribute. The ethtool family validates strictly and rejects nests without the
+// NLA_F_NESTED flag.
+func nested(attrType int) *nl.RtAttr {
+	return nl.NewRtAttr(attrType|int(nl.NLA_F_NESTED), nil)
+}
+
+// attributes holds parsed netlink attributes in the order in which they were received.
+type attributes []syscall.NetlinkRouteAttr
+
+func parseAttributes(b []byte) (attributes, error) {
+	attrs, err := nl.ParseRouteAttr(b)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse netlink attributes, err: %q", err)
+	}
+	return attrs, nil
+}
+
+// get returns the value of the first attribute of type attrType, or nil.
+func (a attributes) get(attrType uint16) []byte {
+	for _, attr := range a {
+		if attr.Attr.Type&nl.NLA_TYPE_MASK == attrType {
+			return attr.Value
+		}
+	}
+	return nil
+}
+
+// all returns the values of all attributes of type attrType.
+func (a attributes) all(attrType uint16) [][]byte {
+	var values [][]byte
+	for _, attr := range a {
+		if attr.Attr.Type&nl.NLA_TYPE_MASK == attrType {
+			values = append(values, attr.Value)
+		}
+	}
+	return values
+}
+
+// lookupUint8 returns the value of the attribute of type attrType and whether the attribute is present.
+func (a attributes) lookupUint8(attrType uint16) (uint8, bool) {
+	v := a.get(attrType)
+	if len(v) < 1 {
+		return 0, false
+	}
+	return v[0], true
+}
+
+// lookupUint32 returns the value of the attribute of type attrType and whether the attribute is present.
+func (a attributes) lookupUint32(attrType uint16) (uint32, bool) {
+	v := a.get(attrType)
+	if len(v) < 4 {
+		return 0, false
+	}
+	return nl.NativeEndian().Uint32(v), true
+}
+
+func (a attributes) uint32(attrType uint16) uint32 {
+	v := a.get(attrType)
+	if len(v) < 4 {
+		return 0
+	}
+	return nl.NativeEndian().Uint32(v)
+}
+
+// bitset is a compact ethtool bitset, see ETHTOOL_A_BITSET_VALUE.
+type bitset struct {
+	size  int
+	words []uint32
+}
+
+func parseCompactBitset(b []byte) (bitset, error) {
+	value, _, err := parseCompactBitsetWithMask(b)
+	return value, err
+}
+
+// parseCompactBitsetWithMask returns the value and the mask of a compact bitset. The mask is empty for bitsets
+// without a mask, see ETHTOOL_A_BITSET_NOMASK.
+func parseCompactBitsetWithMask(b []byte) (bitset, bitset, error) {
+	attrs, err := parseAttributes(b)
+	if err != nil {
+		return bitset{}, bitset{}, err
+	}
+	if attrs.get(ethtoolABitsetBits) != nil {
+		return bitset{}, bitset{}, fmt.Errorf("expected a compact bitset")
+	}
+	size := int(attrs.uint32(ethtoolABitsetSize))
+	value, mask := bitset{size: size}, bitset{size: size}
+	for attrType, bs := range map[uint16]*bitset{ethtoolABitsetValue: &value, ethtoolABitsetMask: &mask} {
+		words := attrs.get(attrType)
+		for i := 0; i+4 <= len(words); i += 4 {
+			bs.words = append(bs.words, nl.NativeEndian().Uint32(words[i:i+4]))
+		}
+	}
+	return value, mask, nil
+}
+
+func (b bitset) isSet(i int) bool {
+	if i < 0 || i >= b.size || i/32 >= len(b.words) {
+		return false
+	}
+	return b.words[i/32]&(1<<(uint(i)%32)) != 0
+}
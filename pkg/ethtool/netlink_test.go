@@ -0,0 +1,193 @@
+package ethtool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+	"k8s.io/utils/pointer"
+)
+
+// fakeNetlink replaces executeRequest for the tests of netlinkBackend. It records the command and the attributes of
+// every request and answers each request with the attributes in reply.
+type fakeNetlink struct {
+	reply    []byte
+	commands []uint8
+	requests [][]byte
+}
+
+// newFakeNetlink replaces executeRequest with a fakeNetlink until the end of test t.
+func newFakeNetlink(t *testing.T, reply []byte) *fakeNetlink {
+	f := &fakeNetlink{reply: reply}
+	execute := executeRequest
+	executeRequest = f.execute
+	t.Cleanup(func() { executeRequest = execute })
+	return f
+}
+
+func (f *fakeNetlink) execute(req *nl.NetlinkRequest) ([][]byte, error) {
+	b := req.Serialize()
+	f.commands = append(f.commands, b[unix.SizeofNlMsghdr])
+	f.requests = append(f.requests, b[unix.SizeofNlMsghdr+nl.SizeofGenlmsg:])
+	return [][]byte{append(make([]byte, nl.SizeofGenlmsg), f.reply...)}, nil
+}
+
+func TestParseCompactBitset(t *testing.T) {
+	bs := nested(ethtoolAFeaturesActive)
+	bs.AddRtAttr(ethtoolABitsetNomask, nil)
+	bs.AddRtAttr(ethtoolABitsetSize, nl.Uint32Attr(40))
+	value := append(nl.Uint32Attr(1<<0|1<<31), nl.Uint32Attr(1<<7)...)
+	bs.AddRtAttr(ethtoolABitsetValue, value)
+
+	// Skip the header of the outer attribute.
+	parsed, err := parseCompactBitset(bs.Serialize()[4:])
+	if err != nil {
+		t.Fatalf("parseCompactBitset: expected to see no error but got %q", err)
+	}
+	for i := 0; i < 48; i++ {
+		expected := i == 0 || i == 31 || i == 39
+		if parsed.isSet(i) != expected {
+			t.Fatalf("parseCompactBitset: expected bit %d to be %t", i, expected)
+		}
+	}
+}
+
+func TestNetlinkOffloadList(t *testing.T) {
+	f := &netlinkFeatures{
+		names: []string{"tx-scatter-gather", "tx-checksum-ipv4", "tx-checksum-ip-generic", "", "highdma",
+			"rx-gro", "tx-scatter-gather-fraglist", "netns-local"},
+		hw:       bitset{size: 8, words: []uint32{1<<0 | 1<<1 | 1<<5 | 1<<6 | 1<<7}},
+		wanted:   bitset{size: 8, words: []uint32{1<<1 | 1<<5 | 1<<6}},
+		active:   bitset{size: 8, words: []uint32{1<<1 | 1<<5}},
+		nochange: bitset{size: 8, words: []uint32{1 << 7}},
+	}
+	expected := OffloadList{
+		"tx-checksumming":            {pointer.Bool(true), nil, nil},
+		"tx-checksum-ipv4":           {pointer.Bool(true), pointer.Bool(false), pointer.Bool(true)},
+		"tx-checksum-ip-generic":     {pointer.Bool(false), pointer.Bool(true), pointer.Bool(false)},
+		"scatter-gather":             {pointer.Bool(false), nil, nil},
+		"tx-scatter-gather":          {pointer.Bool(false), pointer.Bool(false), pointer.Bool(false)},
+		"tx-scatter-gather-fraglist": {pointer.Bool(false), pointer.Bool(false), pointer.Bool(true)},
+		"highdma":                    {pointer.Bool(false), pointer.Bool(true), pointer.Bool(false)},
+		"generic-receive-offload":    {pointer.Bool(true), pointer.Bool(false), pointer.Bool(true)},
+		"netns-local":                {pointer.Bool(false), pointer.Bool(true), pointer.Bool(false)},
+	}
+	if offloadList := f.offloadList(); !offloadList.Equals(expected) {
+		t.Fatalf("offloadList: unexpected differences %v", offloadList.Diff(expected))
+	}
+}
+
+func TestNetlinkRequestEncoding(t *testing.T) {
+	if nl.NativeEndian() != binary.ByteOrder(binary.LittleEndian) {
+		t.Skip("the expected request is little-endian")
+	}
+	fake := newFakeNetlink(t, nil)
+	backend := &netlinkBackend{}
+	if err := backend.SetRings("ens3", map[string]uint32{RingTX: 512, RingRX: 1024}); err != nil {
+		t.Fatalf("SetRings(ens3): expected to see no error but got %q", err)
+	}
+	expected := []byte{
+		16, 0, 1, 0x80, // ETHTOOL_A_RINGS_HEADER | NLA_F_NESTED
+		9, 0, 2, 0, 'e', 'n', 's', '3', 0, 0, 0, 0, // ETHTOOL_A_HEADER_DEV_NAME, padded to 4 bytes
+		8, 0, 6, 0, 0, 4, 0, 0, // ETHTOOL_A_RINGS_RX
+		8, 0, 9, 0, 0, 2, 0, 0, // ETHTOOL_A_RINGS_TX
+	}
+	if len(fake.requests) != 1 || fake.commands[0] != ethtoolMsgRingsSet || !bytes.Equal(fake.requests[0], expected) {
+		t.Fatalf("SetRings(ens3): expected command %d with attributes %v but got commands %v with attributes %v",
+			ethtoolMsgRingsSet, expected, fake.commands, fake.requests)
+	}
+}
+
+func TestNetlinkSetAttributes(t *testing.T) {
+	tcs := []struct {
+		name     string
+		set      func(b *netlinkBackend) error
+		cmd      uint8
+		expected map[uint16][]byte
+	}{
+		{"SetRings", func(b *netlinkBackend) error {
+			return b.SetRings("ens3", map[string]uint32{RingRX: 1024, RingCQESize: 128})
+		}, ethtoolMsgRingsSet, map[uint16][]byte{
+			ethtoolARingsRX:      nl.Uint32Attr(1024),
+			ethtoolARingsCQESize: nl.Uint32Attr(128),
+		}},
+		{"SetChannels", func(b *netlinkBackend) error {
+			return b.SetChannels("ens3", map[string]uint32{ChannelCombined: 16, ChannelOther: 1})
+		}, ethtoolMsgChannelsSet, map[uint16][]byte{
+			ethtoolAChannelsOtherCount:    nl.Uint32Attr(1),
+			ethtoolAChannelsCombinedCount: nl.Uint32Attr(16),
+		}},
+		{"SetCoalesce", func(b *netlinkBackend) error {
+			return b.SetCoalesce("ens3", &Coalesce{RXUsecs: pointer.Uint32(4), TXFrames: pointer.Uint32(32),
+				AdaptiveRX: pointer.Bool(false), AdaptiveTX: pointer.Bool(true)})
+		}, ethtoolMsgCoalesceSet, map[uint16][]byte{
+			ethtoolACoalesceRXUsecs:       nl.Uint32Attr(4),
+			ethtoolACoalesceTXMaxFrames:   nl.Uint32Attr(32),
+			ethtoolACoalesceUseAdaptiveRX: {0},
+			ethtoolACoalesceUseAdaptiveTX: {1},
+		}},
+		{"SetPause", func(b *netlinkBackend) error {
+			return b.SetPause("ens3", &Pause{Autoneg: pointer.Bool(false), TX: pointer.Bool(true)})
+		}, ethtoolMsgPauseSet, map[uint16][]byte{
+			ethtoolAPauseAutoneg: {0},
+			ethtoolAPauseTX:      {1},
+		}},
+	}
+	for _, tc := range tcs {
+		fake := newFakeNetlink(t, nil)
+		if err := tc.set(&netlinkBackend{}); err != nil {
+			t.Fatalf("%s(ens3): expected to see no error but got %q", tc.name, err)
+		}
+		if len(fake.requests) != 1 || fake.commands[0] != tc.cmd {
+			t.Fatalf("%s(ens3): expected a single request with command %d but got commands %v", tc.name, tc.cmd,
+				fake.commands)
+		}
+		attrs, err := parseAttributes(fake.requests[0])
+		if err != nil {
+			t.Fatalf("%s(ens3): expected to see no error but got %q", tc.name, err)
+		}
+		header, err := parseAttributes(attrs.get(ethtoolAHeader))
+		if err != nil {
+			t.Fatalf("%s(ens3): expected to see no error but got %q", tc.name, err)
+		}
+		if devName := header.get(ethtoolAHeaderDevName); !bytes.Equal(devName, nl.ZeroTerminated("ens3")) {
+			t.Fatalf("%s(ens3): expected device name %q but got %q", tc.name, "ens3", devName)
+		}
+		values := map[uint16][]byte{}
+		for _, attr := range attrs {
+			if attr.Attr.Type != ethtoolAHeader|nl.NLA_F_NESTED {
+				values[attr.Attr.Type] = attr.Value
+			}
+		}
+		if !reflect.DeepEqual(values, tc.expected) {
+			t.Fatalf("%s(ens3): expected attributes %v but got %v", tc.name, tc.expected, values)
+		}
+	}
+}
+
+func TestNetlinkGetRings(t *testing.T) {
+	var reply []byte
+	for attr, value := range map[int]uint32{ethtoolARingsRXMax: 4096, ethtoolARingsTXMax: 2048, ethtoolARingsRX: 256,
+		ethtoolARingsTX: 512, ethtoolARingsRXBufLen: 2048} {
+		reply = append(reply, nl.NewRtAttr(attr, nl.Uint32Attr(value)).Serialize()...)
+	}
+	fake := newFakeNetlink(t, reply)
+	rings, err := (&netlinkBackend{}).GetRings("ens3")
+	if err != nil {
+		t.Fatalf("GetRings(ens3): expected to see no error but got %q", err)
+	}
+	expected := &RingParameters{
+		Current: map[string]uint32{RingRX: 256, RingTX: 512, RingRXBufLen: 2048},
+		Max:     map[string]uint32{RingRX: 4096, RingTX: 2048},
+	}
+	if !reflect.DeepEqual(rings, expected) {
+		t.Fatalf("GetRings(ens3): expected %v but got %v", expected, rings)
+	}
+	if len(fake.commands) != 1 || fake.commands[0] != ethtoolMsgRingsGet {
+		t.Fatalf("GetRings(ens3): expected a single request with command %d but got commands %v",
+			ethtoolMsgRingsGet, fake.commands)
+	}
+}
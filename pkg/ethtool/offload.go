@@ -0,0 +1,128 @@
+package ethtool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Offload is the state of an offloading attribute. Fixed and Requested are nil for attributes that stand for several
+// kernel features, e.g. "tx-checksumming".
+type Offload struct {
+	Active    *bool `json:"active"`
+	Fixed     *bool `json:"fixed"`
+	Requested *bool `json:"requested"`
+}
+
+// IsActive returns true if the attribute is on.
+func (o Offload) IsActive() bool {
+	return o.Active != nil && *o.Active
+}
+
+// IsFixed returns true if the attribute cannot be changed.
+func (o Offload) IsFixed() bool {
+	return o.Fixed != nil && *o.Fixed
+}
+
+// Equals returns true if both offloads have the same active, fixed and requested state.
+func (o Offload) Equals(other Offload) bool {
+	return equalBoolPtr(o.Active, other.Active) && equalBoolPtr(o.Fixed, other.Fixed) &&
+		equalBoolPtr(o.Requested, other.Requested)
+}
+
+func (o Offload) String() string {
+	return fmt.Sprintf("{active: %s, fixed: %s, requested: %s}", boolPtrString(o.Active), boolPtrString(o.Fixed),
+		boolPtrString(o.Requested))
+}
+
+// OffloadList holds the offloading attributes of an interface, keyed by their name.
+type OffloadList map[string]Offload
+
+// Equals returns true if both lists contain the same attributes with the same state.
+func (ol OffloadList) Equals(other OffloadList) bool {
+	return len(ol.Diff(other)) == 0
+}
+
+// Diff returns the attributes that differ between ol and other, ordered by name.
+func (ol OffloadList) Diff(other OffloadList) []OffloadDiff {
+	var diffs []OffloadDiff
+	for name, offload := range ol {
+		otherOffload, ok := other[name]
+		if !ok {
+			diffs = append(diffs, OffloadDiff{Name: name, Old: offloadPtr(offload)})
+			continue
+		}
+		if !offload.Equals(otherOffload) {
+			diffs = append(diffs, OffloadDiff{Name: name, Old: offloadPtr(offload), New: offloadPtr(otherOffload)})
+		}
+	}
+	for name, otherOffload := range other {
+		if _, ok := ol[name]; !ok {
+			diffs = append(diffs, OffloadDiff{Name: name, New: offloadPtr(otherOffload)})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+// ActiveStates returns the active state of each attribute.
+func (ol OffloadList) ActiveStates() map[string]bool {
+	states := make(map[string]bool, len(ol))
+	for name, offload := range ol {
+		states[name] = offload.IsActive()
+	}
+	return states
+}
+
+func (ol OffloadList) String() string {
+	names := make([]string, 0, len(ol))
+	for name := range ol {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "%s: %s", name, ol[name])
+	}
+	return "[" + sb.String() + "]"
+}
+
+// OffloadDiff is an attribute that differs between two offload lists. Old is nil if the attribute only exists in the
+// second list, New is nil if it only exists in the first list.
+type OffloadDiff struct {
+	Name string
+	Old  *Offload
+	New  *Offload
+}
+
+func (d OffloadDiff) String() string {
+	before, after := "<none>", "<none>"
+	if d.Old != nil {
+		before = d.Old.String()
+	}
+	if d.New != nil {
+		after = d.New.String()
+	}
+	return fmt.Sprintf("%s: %s -> %s", d.Name, before, after)
+}
+
+func offloadPtr(o Offload) *Offload {
+	return &o
+}
+
+func equalBoolPtr(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func boolPtrString(b *bool) string {
+	if b == nil {
+		return "null"
+	}
+	return status[*b]
+}
@@ -0,0 +1,118 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	PauseAutoneg = "autoneg"
+	PauseRX      = "rx"
+	PauseTX      = "tx"
+)
+
+// pauseFields lists the pause parameters in the order of ethtool -A. label is the name in the output of ethtool -a,
+// attr is the ETHTOOL_A_PAUSE_* attribute.
+var pauseFields = []struct {
+	name  string
+	label string
+	attr  uint16
+}{
+	{PauseAutoneg, "Autonegotiate", ethtoolAPauseAutoneg},
+	{PauseRX, "RX", ethtoolAPauseRX},
+	{PauseTX, "TX", ethtoolAPauseTX},
+}
+
+// Pause holds the link-level flow control parameters of an interface, see ethtool -A. Parameters that are not set are
+// not changed.
+type Pause struct {
+	Autoneg *bool `json:"autoneg,omitempty"`
+	RX      *bool `json:"rx,omitempty"`
+	TX      *bool `json:"tx,omitempty"`
+	// PFCAware leaves the pause parameters unchanged while priority flow control (PFC) is enabled for any priority of
+	// the interface, see IEEE 802.1Qbb. Link-level pause frames and PFC are mutually exclusive, and most drivers reject
+	// pause parameters while PFC is enabled. Interfaces whose driver does not support DCB never have PFC enabled.
+	PFCAware *bool `json:"pfc-aware,omitempty"`
+}
+
+// fields returns a pointer to each pause parameter field, keyed by its ethtool -A name.
+func (p *Pause) fields() map[string]**bool {
+	return map[string]**bool{
+		PauseAutoneg: &p.Autoneg,
+		PauseRX:      &p.RX,
+		PauseTX:      &p.TX,
+	}
+}
+
+// IsEmpty returns true if no pause parameter is set. PFCAware is not a parameter of the interface.
+func (p *Pause) IsEmpty() bool {
+	if p == nil {
+		return true
+	}
+	for _, field := range p.fields() {
+		if *field != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Copy returns a deep copy of p.
+func (p *Pause) Copy() *Pause {
+	if p == nil {
+		return nil
+	}
+	copied := &Pause{}
+	dst := copied.fields()
+	for name, field := range p.fields() {
+		if *field != nil {
+			v := **field
+			*dst[name] = &v
+		}
+	}
+	if p.PFCAware != nil {
+		v := *p.PFCAware
+		copied.PFCAware = &v
+	}
+	return copied
+}
+
+// skip returns true if the pause parameters of interface iface must be left unchanged because p is PFC aware and PFC
+// is enabled.
+func (p *Pause) skip(b Backend, iface string) (bool, error) {
+	if p.PFCAware == nil || !*p.PFCAware {
+		return false, nil
+	}
+	enabled, err := b.PFCEnabled(iface)
+	if err != nil {
+		return false, fmt.Errorf("could not read priority flow control of interface %s, err: %q", iface, err)
+	}
+	return enabled, nil
+}
+
+// diff returns a description of every parameter that is set in p and whose value in current differs.
+func (p *Pause) diff(current *Pause) []string {
+	var mismatches []string
+	currentFields := current.fields()
+	for name, field := range p.fields() {
+		if *field == nil {
+			continue
+		}
+		v := *currentFields[name]
+		if v == nil {
+			mismatches = append(mismatches, fmt.Sprintf("pause parameter %q is not supported", name))
+		} else if *v != **field {
+			mismatches = append(mismatches, fmt.Sprintf("pause parameter %q is %s, expected %s", name, status[*v],
+				status[**field]))
+		}
+	}
+	return mismatches
+}
+
+func (p Pause) String() string {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,57 @@
+package ethtool
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/pointer"
+)
+
+const (
+	ens3PauseOutput = `Pause parameters for ens3:
+Autonegotiate:	on
+RX:		on
+TX:		off
+RX negotiated:	on
+TX negotiated:	off
+
+`
+)
+
+func TestExecBackendPause(t *testing.T) {
+	fake := newFakeCommand(t, []string{"-a", "ens3"}, ens3PauseOutput, "-A", nil)
+
+	backend := execBackend{}
+	pause, err := backend.GetPause("ens3")
+	if err != nil {
+		t.Fatalf("GetPause(ens3): expected to see no error but got %q", err)
+	}
+	expected := &Pause{Autoneg: pointer.Bool(true), RX: pointer.Bool(true), TX: pointer.Bool(false)}
+	if !reflect.DeepEqual(pause, expected) {
+		t.Fatalf("GetPause(ens3): expected %v but got %v", expected, pause)
+	}
+	if err := backend.SetPause("ens3", &Pause{TX: pointer.Bool(false), Autoneg: pointer.Bool(false),
+		PFCAware: pointer.Bool(true)}); err != nil {
+		t.Fatalf("SetPause(ens3): expected to see no error but got %q", err)
+	}
+	expectedParameters := [][]string{{"-A", "ens3", "autoneg", "off", "tx", "off"}}
+	if !reflect.DeepEqual(fake.setParameters, expectedParameters) {
+		t.Fatalf("SetPause(ens3): expected parameters %v but got %v", expectedParameters, fake.setParameters)
+	}
+}
+
+func TestValidatePause(t *testing.T) {
+	ethtoolConfigs := EthtoolConfigs{"eth0": {Self: &Settings{Pause: &Pause{PFCAware: pointer.Bool(true)}}}}
+	expected := ValidationErrors{{
+		Path:  "ethtool.eth0.self",
+		Value: `{"pause":{"pfc-aware":true}}`,
+		Rule:  "must configure at least one setting",
+	}, {
+		Path:  "ethtool.eth0.self.pause",
+		Value: `{"pfc-aware":true}`,
+		Rule:  `must configure at least one of ["autoneg" "rx" "tx"]`,
+	}}
+	if errs := ethtoolConfigs.Validate(ConfigVersionV1); !reflect.DeepEqual(errs, expected) {
+		t.Fatalf("Validate(%s): expected %v but got %v", ethtoolConfigs, expected, errs)
+	}
+}
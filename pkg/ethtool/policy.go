@@ -0,0 +1,214 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+
+	"github.com/andreaskaris/cni-ethtool/pkg/helpers"
+)
+
+var (
+	// defaultPolicySides are the classifiers that per-pod overrides may configure if Policy.Sides is empty.
+	defaultPolicySides = []string{SelfClassifier}
+	// deniedPolicySides can never be configured by per-pod overrides: the lower device or physical function is shared
+	// by all pods on top of it.
+	deniedPolicySides = []string{ParentClassifier}
+	// peerSections are the sections that Policy.AllowedPeerSections may allow for "peer". Section "features" is
+	// restricted by Policy.AllowedFeatures and Policy.DeniedFeatures instead, section "vf" is never allowed.
+	peerSections = []string{ringsKey, channelsKey, coalesceKey, pauseKey, linkKey, privFlagsKey, rssKey, flowRulesKey}
+)
+
+// Policy restricts the settings that per-pod overrides, e.g. from pod annotations or from runtimeConfig, may change.
+// Settings of the plugin configuration and of policy files are not restricted. On top of Policy, per-pod overrides may
+// never configure "parent" nor section "vf", and may only configure "peer" of veth interfaces: the peer of other
+// interfaces, e.g. of macvlan interfaces, is the lower device or physical function that all pods share.
+type Policy struct {
+	// Sides lists the classifiers that per-pod overrides may configure, "self" and "peer". Defaults to "self".
+	Sides []string `json:"sides,omitempty"`
+	// AllowedFeatures lists the offloading attributes that per-pod overrides may change, as globs, e.g. "rx-gro*".
+	// If empty, all offloading attributes may be changed. A long name such as "tx-checksumming" is only allowed if
+	// the name itself or every kernel feature that it stands for is allowed.
+	AllowedFeatures []string `json:"allowedFeatures,omitempty"`
+	// DeniedFeatures lists the offloading attributes that per-pod overrides may not change, as globs. It takes
+	// precedence over AllowedFeatures.
+	DeniedFeatures []string `json:"deniedFeatures,omitempty"`
+	// AllowedPeerSections lists the sections besides "features" that per-pod overrides may configure for "peer", e.g.
+	// "rings". The peer is outside of the pod, so all other sections of "peer" are denied.
+	AllowedPeerSections []string `json:"allowedPeerSections,omitempty"`
+}
+
+// sides returns the classifiers that per-pod overrides may configure.
+func (p Policy) sides() []string {
+	if len(p.Sides) == 0 {
+		return defaultPolicySides
+	}
+	return p.Sides
+}
+
+// Validate checks p and returns every problem that it finds, or nil. path is the JSON path of p, e.g. "policy".
+func (p Policy) Validate(path string) ValidationErrors {
+	var errs ValidationErrors
+	for i, side := range p.Sides {
+		switch {
+		case slices.Contains(deniedPolicySides, side):
+			errs = append(errs, ValidationError{
+				Path:  fmt.Sprintf("%s.sides[%d]", path, i),
+				Value: fmt.Sprintf("%q", side),
+				Rule:  "per-pod overrides may never configure this classifier",
+			})
+		case side != SelfClassifier && side != PeerClassifier:
+			errs = append(errs, ValidationError{
+				Path:  fmt.Sprintf("%s.sides[%d]", path, i),
+				Value: fmt.Sprintf("%q", side),
+				Rule:  fmt.Sprintf("unknown classifier, expected one of %q", []string{SelfClassifier, PeerClassifier}),
+			})
+		}
+	}
+	for i, section := range p.AllowedPeerSections {
+		if !slices.Contains(peerSections, section) {
+			errs = append(errs, ValidationError{
+				Path:  fmt.Sprintf("%s.allowedPeerSections[%d]", path, i),
+				Value: fmt.Sprintf("%q", section),
+				Rule:  fmt.Sprintf("unknown section, expected one of %q", peerSections),
+			})
+		}
+	}
+	for field, patterns := range map[string][]string{
+		"allowedFeatures": p.AllowedFeatures,
+		"deniedFeatures":  p.DeniedFeatures,
+	} {
+		for i, pattern := range patterns {
+			if _, err := matchAny([]string{pattern}, ""); err != nil {
+				errs = append(errs, ValidationError{
+					Path:  fmt.Sprintf("%s.%s[%d]", path, field, i),
+					Value: fmt.Sprintf("%q", pattern),
+					Rule:  fmt.Sprintf("invalid pattern: %s", err),
+				})
+			}
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs
+}
+
+// Check returns every setting of overrides that p does not allow, or nil. path is the JSON path of overrides, e.g.
+// "runtimeConfig.ethtool". p must be valid, see Validate.
+func (p Policy) Check(path string, overrides EthtoolConfigs) ValidationErrors {
+	var errs ValidationErrors
+	for key, ethtoolConfig := range overrides {
+		for _, classifier := range []string{SelfClassifier, PeerClassifier, ParentClassifier} {
+			settings := ethtoolConfig.Get(classifier)
+			if settings == nil {
+				continue
+			}
+			classifierPath := joinPath(path, key, classifier)
+			if !slices.Contains(p.sides(), classifier) {
+				errs = append(errs, ValidationError{
+					Path:  classifierPath,
+					Value: settings.String(),
+					Rule:  fmt.Sprintf("per-pod overrides may only configure %q", p.sides()),
+				})
+				continue
+			}
+			// Spoof checking and trust of a VF protect the rest of the network from the pod.
+			if settings.VF != nil {
+				errs = append(errs, ValidationError{
+					Path:  joinPath(classifierPath, vfKey),
+					Value: settings.VF.String(),
+					Rule:  "per-pod overrides may never configure this section",
+				})
+			}
+			if classifier == PeerClassifier {
+				errs = append(errs, p.checkPeerSections(classifierPath, settings)...)
+			}
+			for feature := range settings.Features {
+				if rule := p.checkFeature(feature); rule != "" {
+					errs = append(errs, ValidationError{
+						Path:  settings.featurePath(classifierPath, feature),
+						Value: fmt.Sprintf("%q", feature),
+						Rule:  rule,
+					})
+				}
+			}
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs
+}
+
+// checkPeerSections returns every section of settings, the settings of "peer" at path, that p does not allow.
+func (p Policy) checkPeerSections(path string, settings *Settings) ValidationErrors {
+	var errs ValidationErrors
+	rule := fmt.Sprintf("per-pod overrides may only configure sections %q of %q", p.allowedPeerSections(),
+		PeerClassifier)
+	for section, value := range settings.sections() {
+		if !slices.Contains(peerSections, section) || slices.Contains(p.AllowedPeerSections, section) {
+			continue
+		}
+		b, _ := json.Marshal(value)
+		errs = append(errs, ValidationError{Path: joinPath(path, section), Value: string(b), Rule: rule})
+	}
+	return errs
+}
+
+// allowedPeerSections returns the sections that per-pod overrides may configure for "peer".
+func (p Policy) allowedPeerSections() []string {
+	return append([]string{featuresKey}, p.AllowedPeerSections...)
+}
+
+// CheckLinkType returns an error if overrides, per-pod overrides keyed by interface name, configure "peer" of
+// interface interfaceName, which is of type linkType. Only the peer of a veth interface belongs to the pod alone.
+func (p Policy) CheckLinkType(overrides EthtoolConfigs, interfaceName, linkType string) error {
+	if overrides[interfaceName].GetPeer() == nil || linkType == helpers.TypeVeth {
+		return nil
+	}
+	return fmt.Errorf("per-pod overrides may only configure %q of veth interfaces, interface %s is of type %s, "+
+		"its peer is shared with other pods", PeerClassifier, interfaceName, linkType)
+}
+
+// checkFeature returns the rule that offloading attribute feature breaks, or "" if p allows it. Long names such as
+// "tx-checksumming" are checked together with the kernel features that they stand for.
+func (p Policy) checkFeature(feature string) string {
+	names := []string{feature}
+	if lf, ok := findLegacyFeature(feature); ok {
+		names = append(names, lf.kernelNames...)
+	}
+	for _, name := range names {
+		if denied, _ := matchAny(p.DeniedFeatures, name); denied {
+			return fmt.Sprintf("per-pod overrides may not change offloading attribute %q", name)
+		}
+	}
+	if len(p.AllowedFeatures) == 0 {
+		return ""
+	}
+	if allowed, _ := matchAny(p.AllowedFeatures, feature); allowed {
+		return ""
+	}
+	if len(names) == 1 {
+		return fmt.Sprintf("per-pod overrides may only change offloading attributes %q", p.AllowedFeatures)
+	}
+	for _, name := range names[1:] {
+		if allowed, _ := matchAny(p.AllowedFeatures, name); !allowed {
+			return fmt.Sprintf("per-pod overrides may only change offloading attributes %q, but %q also changes %q",
+				p.AllowedFeatures, feature, name)
+		}
+	}
+	return ""
+}
+
+// matchAny returns true if name matches any of the provided globs, see path.Match.
+func matchAny(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,96 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	policy := Policy{Sides: []string{"self", "parent", "pear"}, DeniedFeatures: []string{"rx-["},
+		AllowedPeerSections: []string{"rings", "vf"}}
+	expected := ValidationErrors{{
+		Path:  "policy.allowedPeerSections[1]",
+		Value: `"vf"`,
+		Rule: `unknown section, expected one of ["rings" "channels" "coalesce" "pause" "link" "privFlags" "rss" ` +
+			`"flowRules"]`,
+	}, {
+		Path:  "policy.deniedFeatures[0]",
+		Value: `"rx-["`,
+		Rule:  "invalid pattern: syntax error in pattern",
+	}, {
+		Path:  "policy.sides[1]",
+		Value: `"parent"`,
+		Rule:  "per-pod overrides may never configure this classifier",
+	}, {
+		Path:  "policy.sides[2]",
+		Value: `"pear"`,
+		Rule:  `unknown classifier, expected one of ["self" "peer"]`,
+	}}
+	if errs := policy.Validate("policy"); !reflect.DeepEqual(errs, expected) {
+		t.Fatalf("Validate(%v): expected %v but got %v", policy, expected, errs)
+	}
+	if errs := (Policy{}).Validate("policy"); errs != nil {
+		t.Fatalf("Validate: expected the default policy to be valid but got %v", errs)
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	tcs := []struct {
+		policy   Policy
+		in       string
+		expected []string
+	}{
+		{Policy{}, `{"eth0": {"self": {"features": {"rx-gro": false}, "rings": {"rx": "max"}}}}`, nil},
+		{Policy{}, `{"eth0": {"peer": {"features": {"rx-gro": false}}}}`, []string{"x.eth0.peer"}},
+		{Policy{Sides: []string{"self", "peer"}}, `{"eth0": {"peer": {"features": {"rx-gro": false}}},
+			"net*": {"parent": {"vf": {"trust": true}}}}`, []string{"x.net*.parent"}},
+		{Policy{Sides: []string{"peer"}}, `{"eth0": {"peer": {"vf": {"trust": true}}}}`, []string{"x.eth0.peer.vf"}},
+		{Policy{Sides: []string{"peer"}}, `{"eth0": {"peer": {"rings": {"rx": 512}, "channels": {"combined": 2}}}}`,
+			[]string{"x.eth0.peer.channels", "x.eth0.peer.rings"}},
+		{Policy{Sides: []string{"peer"}, AllowedPeerSections: []string{"rings"}},
+			`{"eth0": {"peer": {"rings": {"rx": 512}, "channels": {"combined": 2}}}}`, []string{"x.eth0.peer.channels"}},
+		{Policy{AllowedFeatures: []string{"rx-gro*", "tx-checksum-*"}, DeniedFeatures: []string{"rx-gro-hw"}},
+			`{"eth0": {"self": {"rx-gro": false, "rx-gro-hw": false, "tx-checksumming": false,
+				"features": {"tx-tcp-segmentation": false}}}}`,
+			[]string{"x.eth0.self.features.tx-tcp-segmentation", "x.eth0.self.rx-gro-hw"}},
+		{Policy{AllowedFeatures: []string{"tx-checksum-ip*"}},
+			`{"eth0": {"self": {"features": {"tx-checksumming": false}}}}`,
+			[]string{"x.eth0.self.features.tx-checksumming"}},
+		{Policy{DeniedFeatures: []string{"tx-checksum-ipv6"}},
+			`{"eth0": {"self": {"features": {"tx-checksumming": false}}}}`,
+			[]string{"x.eth0.self.features.tx-checksumming"}},
+	}
+	for _, tc := range tcs {
+		var overrides EthtoolConfigs
+		if err := json.Unmarshal([]byte(tc.in), &overrides); err != nil {
+			t.Fatalf("Unmarshal(%s): expected to see no error but got %q", tc.in, err)
+		}
+		var paths []string
+		for _, e := range tc.policy.Check("x", overrides) {
+			paths = append(paths, e.Path)
+		}
+		if !reflect.DeepEqual(paths, tc.expected) {
+			t.Fatalf("Check(%v, %s): expected errors for %v but got %v", tc.policy, tc.in, tc.expected,
+				tc.policy.Check("x", overrides))
+		}
+	}
+}
+
+func TestPolicyCheckLinkType(t *testing.T) {
+	var overrides EthtoolConfigs
+	in := `{"eth0": {"peer": {"features": {"rx-gro": false}}}, "net1": {"self": {"features": {"rx-gro": false}}}}`
+	if err := json.Unmarshal([]byte(in), &overrides); err != nil {
+		t.Fatalf("Unmarshal(%s): expected to see no error but got %q", in, err)
+	}
+	policy := Policy{Sides: []string{"self", "peer"}}
+	if err := policy.CheckLinkType(overrides, "eth0", "veth"); err != nil {
+		t.Fatalf("CheckLinkType(eth0, veth): expected to see no error but got %q", err)
+	}
+	if err := policy.CheckLinkType(overrides, "net1", "macvlan"); err != nil {
+		t.Fatalf("CheckLinkType(net1, macvlan): expected to see no error but got %q", err)
+	}
+	if err := policy.CheckLinkType(overrides, "eth0", "macvlan"); err == nil {
+		t.Fatalf("CheckLinkType(eth0, macvlan): expected to see an error but got nil")
+	}
+}
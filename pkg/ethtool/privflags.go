@@ -0,0 +1,57 @@
+package ethtool
+
+import (
+	"fmt"
+	"sort"
+)
+
+// privFlagNames returns the names of the private flags of a device in alphabetical order.
+func privFlagNames(current map[string]bool) []string {
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unknownPrivFlags returns the private flags of privFlags that the device does not have, in alphabetical order.
+// current holds all private flags of the device.
+func unknownPrivFlags(current, privFlags map[string]bool) []string {
+	var unknown []string
+	for name := range privFlags {
+		if _, ok := current[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// ValidatePrivFlags checks the private flags that es configures for classifier of interface interfaceName against the
+// ETH_SS_PRIV_FLAGS string set of device iface, see ValidateFeatures.
+func (es EthtoolConfigs) ValidatePrivFlags(b Backend, interfaceName, classifier, iface string) (ValidationErrors,
+	error) {
+	key := interfaceName
+	if es[interfaceName].key != "" {
+		key = es[interfaceName].key
+	}
+	settings := es[interfaceName].Get(classifier)
+	if settings == nil || len(settings.PrivFlags) == 0 {
+		return nil, nil
+	}
+	current, err := b.GetPrivFlags(iface)
+	if err != nil {
+		return nil, fmt.Errorf("could not read private flags of interface %s, err: %q", iface, err)
+	}
+	var errs ValidationErrors
+	for _, name := range unknownPrivFlags(current, settings.PrivFlags) {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(configPath, key, classifier, privFlagsKey, name),
+			Value: fmt.Sprintf("%q", name),
+			Rule: fmt.Sprintf("interface %s does not offer this private flag, valid flags are %q", iface,
+				privFlagNames(current)),
+		})
+	}
+	return errs, nil
+}
@@ -0,0 +1,58 @@
+package ethtool
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	ens3PrivFlagsOutput = `Private flags for ens3:
+link-down-on-close     : off
+fw-lldp-agent          : on
+vf-true-promisc-support: off
+`
+)
+
+func TestExecBackendPrivFlags(t *testing.T) {
+	fake := newFakeCommand(t, []string{"--show-priv-flags", "ens3"}, ens3PrivFlagsOutput, "--set-priv-flags", nil)
+
+	backend := execBackend{}
+	privFlags, err := backend.GetPrivFlags("ens3")
+	if err != nil {
+		t.Fatalf("GetPrivFlags(ens3): expected to see no error but got %q", err)
+	}
+	expected := map[string]bool{"link-down-on-close": false, "fw-lldp-agent": true, "vf-true-promisc-support": false}
+	if !reflect.DeepEqual(privFlags, expected) {
+		t.Fatalf("GetPrivFlags(ens3): expected %v but got %v", expected, privFlags)
+	}
+	if err := backend.SetPrivFlags("ens3", map[string]bool{"link-down-on-close": true,
+		"fw-lldp-agent": false}); err != nil {
+		t.Fatalf("SetPrivFlags(ens3): expected to see no error but got %q", err)
+	}
+	expectedParameters := [][]string{{"--set-priv-flags", "ens3", "fw-lldp-agent", "off", "link-down-on-close", "on"}}
+	if !reflect.DeepEqual(fake.setParameters, expectedParameters) {
+		t.Fatalf("SetPrivFlags(ens3): expected parameters %v but got %v", expectedParameters, fake.setParameters)
+	}
+}
+
+func TestValidatePrivFlags(t *testing.T) {
+	backend := newFakeBackend()
+	ethtoolConfigs := EthtoolConfigs{"eth0": {Self: &Settings{PrivFlags: map[string]bool{
+		"disable-fw-lldp": true,
+		"rx_cqe_compress": true,
+	}}}}
+	expected := ValidationErrors{{
+		Path:  "ethtool.eth0.self.privFlags.rx_cqe_compress",
+		Value: `"rx_cqe_compress"`,
+		Rule:  `interface eth0 does not offer this private flag, valid flags are ["disable-fw-lldp" "link-down-on-close"]`,
+	}}
+	errs, err := ethtoolConfigs.ValidatePrivFlags(backend, "eth0", SelfClassifier, "eth0")
+	if err != nil || !reflect.DeepEqual(errs, expected) {
+		t.Fatalf("ValidatePrivFlags: expected %v but got %v, err: %q", expected, errs, err)
+	}
+	if errs, err := ethtoolConfigs.ValidatePrivFlags(backend, "eth0", PeerClassifier, "eth0"); err != nil ||
+		errs != nil {
+		t.Fatalf("ValidatePrivFlags: expected no errors without settings for %q but got %v, err: %q",
+			PeerClassifier, errs, err)
+	}
+}
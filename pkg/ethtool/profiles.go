@@ -0,0 +1,82 @@
+package ethtool
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// profileKey is the key of EthtoolConfig that selects a profile.
+	profileKey = "profile"
+	// profilesPath is the JSON path of Profiles inside the plugin configuration.
+	profilesPath = "profiles"
+)
+
+// Profiles holds named bundles of settings, e.g. "low-latency", that interfaces select with "profile":
+//
+//	"profiles": {"low-latency": {"self": {"coalesce": {"adaptive-rx": false, "rx-usecs": 0}}}},
+//	"ethtool": {"eth0": {"profile": "low-latency", "self": {"features": {"rx-gro": false}}}}
+//
+// The settings of the interface override the settings of the profile per key, see EthtoolConfig.Merge.
+type Profiles map[string]EthtoolConfig
+
+// Validate checks ps against the schema of the provided configuration version and returns every problem that it
+// finds, or nil. Profiles do not need to configure "self" and cannot select other profiles.
+func (ps Profiles) Validate(version string) ValidationErrors {
+	var errs ValidationErrors
+	for name, profile := range ps {
+		path := joinPath(profilesPath, name)
+		if profile.Profile != "" {
+			errs = append(errs, ValidationError{
+				Path:  joinPath(path, profileKey),
+				Value: fmt.Sprintf("%q", profile.Profile),
+				Rule:  "profiles cannot select other profiles",
+			})
+		}
+		if profile.Self == nil && profile.Peer == nil && profile.Parent == nil && len(profile.unknownClassifiers) == 0 {
+			errs = append(errs, ValidationError{Path: path, Value: profile.String(), Rule: "must not be empty"})
+		}
+		errs = append(errs, profile.validate(path, version, false)...)
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs
+}
+
+// names returns the names of all profiles in alphabetical order.
+func (ps Profiles) names() []string {
+	names := make([]string, 0, len(ps))
+	for name := range ps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveProfiles returns a copy of es in which every interface that selects a profile holds the settings of the
+// profile, overridden by its own settings. path is the JSON path of es, e.g. "ethtool". It returns an error for every
+// interface that selects an unknown profile.
+func (es EthtoolConfigs) ResolveProfiles(path string, profiles Profiles) (EthtoolConfigs, ValidationErrors) {
+	var errs ValidationErrors
+	resolved := make(EthtoolConfigs, len(es))
+	for key, ethtoolConfig := range es {
+		if ethtoolConfig.Profile == "" {
+			resolved[key] = ethtoolConfig
+			continue
+		}
+		profile, ok := profiles[ethtoolConfig.Profile]
+		if !ok {
+			errs = append(errs, ValidationError{
+				Path:  joinPath(path, key, profileKey),
+				Value: fmt.Sprintf("%q", ethtoolConfig.Profile),
+				Rule:  fmt.Sprintf("unknown profile, expected one of %q", profiles.names()),
+			})
+			continue
+		}
+		resolved[key] = profile.Merge(ethtoolConfig)
+	}
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+		return nil, errs
+	}
+	return resolved, nil
+}
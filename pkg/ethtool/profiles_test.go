@@ -0,0 +1,75 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	var profiles Profiles
+	in := `{"low-latency": {"self": {"features": {"rx-gro": false}, "coalesce": {"rx-usecs": 0}}},
+		"no-csum": {"peer": {"tx-checksumming": false}}}`
+	if err := json.Unmarshal([]byte(in), &profiles); err != nil {
+		t.Fatalf("Unmarshal(%s): expected to see no error but got %q", in, err)
+	}
+	if errs := profiles.Validate(ConfigVersionLegacy); errs != nil {
+		t.Fatalf("Validate(%s): expected to see no errors but got %v", in, errs)
+	}
+
+	var ethtoolConfigs EthtoolConfigs
+	in = `{"eth0": {"profile": "low-latency", "self": {"features": {"rx-gro": true, "tx-tcp-segmentation": false}}},
+		"net*": {"profile": "no-csum", "self": {"rings": {"rx": "max"}}}, "eth1": {"self": {"rx-gro": false}}}`
+	if err := json.Unmarshal([]byte(in), &ethtoolConfigs); err != nil {
+		t.Fatalf("Unmarshal(%s): expected to see no error but got %q", in, err)
+	}
+	resolved, errs := ethtoolConfigs.ResolveProfiles("ethtool", profiles)
+	if errs != nil {
+		t.Fatalf("ResolveProfiles: expected to see no errors but got %v", errs)
+	}
+	var expected EthtoolConfigs
+	out := `{"eth0": {"self": {"features": {"rx-gro": true, "tx-tcp-segmentation": false}, "coalesce": {"rx-usecs": 0}}},
+		"net*": {"self": {"rings": {"rx": "max"}}, "peer": {"tx-checksumming": false}}, "eth1": {"self": {"rx-gro": false}}}`
+	if err := json.Unmarshal([]byte(out), &expected); err != nil {
+		t.Fatalf("Unmarshal(%s): expected to see no error but got %q", out, err)
+	}
+	if resolved.String() != expected.String() {
+		t.Fatalf("ResolveProfiles: expected %s but got %s", expected, resolved)
+	}
+	if errs := resolved.Validate(ConfigVersionLegacy); errs != nil {
+		t.Fatalf("Validate(%s): expected to see no errors but got %v", resolved, errs)
+	}
+
+	unknown := EthtoolConfigs{"eth0": {Profile: "throughput"}}
+	expectedErrs := ValidationErrors{{
+		Path:  "ethtool.eth0.profile",
+		Value: `"throughput"`,
+		Rule:  `unknown profile, expected one of ["low-latency" "no-csum"]`,
+	}}
+	if _, errs := unknown.ResolveProfiles("ethtool", profiles); !reflect.DeepEqual(errs, expectedErrs) {
+		t.Fatalf("ResolveProfiles(%s): expected %v but got %v", unknown, expectedErrs, errs)
+	}
+}
+
+func TestProfilesValidate(t *testing.T) {
+	in := `{"nested": {"profile": "other", "self": {"rx-gro": false}}, "empty": {},
+		"legacy": {"peer": {"rx-gro": false}}}`
+	var profiles Profiles
+	if err := json.Unmarshal([]byte(in), &profiles); err != nil {
+		t.Fatalf("Unmarshal(%s): expected to see no error but got %q", in, err)
+	}
+	var paths []string
+	for _, e := range profiles.Validate(ConfigVersionV1) {
+		paths = append(paths, e.Path)
+	}
+	expected := []string{"profiles.empty", "profiles.legacy.peer.rx-gro", "profiles.nested.profile",
+		"profiles.nested.self.rx-gro"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Validate(%s): expected errors for %v but got %v", in, expected, profiles.Validate(ConfigVersionV1))
+	}
+
+	var ethtoolConfig EthtoolConfig
+	if err := json.Unmarshal([]byte(`{"profile": 1}`), &ethtoolConfig); err == nil {
+		t.Fatalf("Unmarshal: expected to see an error for a profile that is not a string")
+	}
+}
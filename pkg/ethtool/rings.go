@@ -0,0 +1,135 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	RingRX       = "rx"
+	RingRXMini   = "rx-mini"
+	RingRXJumbo  = "rx-jumbo"
+	RingTX       = "tx"
+	RingRXBufLen = "rx-buf-len"
+	RingCQESize  = "cqe-size"
+
+	// ringSizeMax selects the maximum that the device reports for a ring.
+	ringSizeMax = "max"
+)
+
+// ringFields lists the ring parameters in the order of ethtool -G. label is the name in the output of ethtool -g, attr
+// and maxAttr are the ETHTOOL_A_RINGS_* attributes of the current value and of the maximum. A maxAttr of 0 means that
+// the kernel does not report a maximum.
+var ringFields = []struct {
+	name    string
+	label   string
+	attr    uint16
+	maxAttr uint16
+}{
+	{RingRX, "RX", ethtoolARingsRX, ethtoolARingsRXMax},
+	{RingRXMini, "RX Mini", ethtoolARingsRXMini, ethtoolARingsRXMiniMax},
+	{RingRXJumbo, "RX Jumbo", ethtoolARingsRXJumbo, ethtoolARingsRXJumboMax},
+	{RingTX, "TX", ethtoolARingsTX, ethtoolARingsTXMax},
+	{RingRXBufLen, "RX Buf Len", ethtoolARingsRXBufLen, 0},
+	{RingCQESize, "CQE Size", ethtoolARingsCQESize, 0},
+}
+
+// Rings holds the ring buffer sizes of an interface, see ethtool -G. Parameters that are not set are not changed.
+type Rings struct {
+	RX       *RingSize `json:"rx,omitempty"`
+	RXMini   *RingSize `json:"rx-mini,omitempty"`
+	RXJumbo  *RingSize `json:"rx-jumbo,omitempty"`
+	TX       *RingSize `json:"tx,omitempty"`
+	RXBufLen *RingSize `json:"rx-buf-len,omitempty"`
+	CQESize  *RingSize `json:"cqe-size,omitempty"`
+}
+
+// fields returns a pointer to each ring parameter field, keyed by its ethtool -G name.
+func (r *Rings) fields() map[string]**RingSize {
+	return map[string]**RingSize{
+		RingRX:       &r.RX,
+		RingRXMini:   &r.RXMini,
+		RingRXJumbo:  &r.RXJumbo,
+		RingTX:       &r.TX,
+		RingRXBufLen: &r.RXBufLen,
+		RingCQESize:  &r.CQESize,
+	}
+}
+
+// IsEmpty returns true if no ring parameter is set.
+func (r *Rings) IsEmpty() bool {
+	for _, field := range r.fields() {
+		if *field != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve returns the value of each configured ring parameter, keyed by its ethtool -G name. "max" is replaced with the
+// maximum that the device reports.
+func (r *Rings) resolve(params *RingParameters) (map[string]uint32, error) {
+	values := map[string]uint32{}
+	for name, field := range r.fields() {
+		size := *field
+		if size == nil {
+			continue
+		}
+		if !size.Max {
+			values[name] = size.Value
+			continue
+		}
+		maximum, ok := params.Max[name]
+		if !ok || maximum == 0 {
+			return nil, fmt.Errorf("device does not report a maximum for ring parameter %q", name)
+		}
+		values[name] = maximum
+	}
+	return values, nil
+}
+
+// RingSize is the value of a ring parameter. It is either a number or "max" for the maximum that the device
+// supports.
+type RingSize struct {
+	Value uint32
+	Max   bool
+}
+
+func (r *RingSize) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		if s != ringSizeMax {
+			return fmt.Errorf("invalid ring size %q, expected a number or %q", s, ringSizeMax)
+		}
+		*r = RingSize{Max: true}
+		return nil
+	}
+	var v uint32
+	if err := json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("invalid ring size %s, expected a number or %q", b, ringSizeMax)
+	}
+	*r = RingSize{Value: v}
+	return nil
+}
+
+func (r RingSize) MarshalJSON() ([]byte, error) {
+	if r.Max {
+		return json.Marshal(ringSizeMax)
+	}
+	return json.Marshal(r.Value)
+}
+
+func (r RingSize) String() string {
+	if r.Max {
+		return ringSizeMax
+	}
+	return strconv.FormatUint(uint64(r.Value), 10)
+}
+
+// RingParameters are the ring parameters that a device reports, keyed by their ethtool -G name. Parameters that the
+// device or the kernel does not support are missing.
+type RingParameters struct {
+	Current map[string]uint32
+	Max     map[string]uint32
+}
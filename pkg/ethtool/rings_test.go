@@ -0,0 +1,105 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	ens3RingsOutput = `Ring parameters for ens3:
+Pre-set maximums:
+RX:			4096
+RX Mini:		n/a
+RX Jumbo:		n/a
+TX:			4096
+Current hardware settings:
+RX:			256
+RX Mini:		n/a
+RX Jumbo:		n/a
+TX:			256
+RX Buf Len:		n/a
+CQE Size:		n/a
+TX Push:		off
+TCP data split:		n/a
+`
+)
+
+func TestRingSizeJSON(t *testing.T) {
+	tcs := []struct {
+		in       string
+		expected RingSize
+		errStr   string
+	}{
+		{`4096`, RingSize{Value: 4096}, ""},
+		{`"max"`, RingSize{Max: true}, ""},
+		{`"min"`, RingSize{}, "invalid ring size"},
+		{`-1`, RingSize{}, "invalid ring size"},
+		{`true`, RingSize{}, "invalid ring size"},
+	}
+	for _, tc := range tcs {
+		var size RingSize
+		err := json.Unmarshal([]byte(tc.in), &size)
+		if tc.errStr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.errStr) {
+				t.Fatalf("Unmarshal(%s): expected to see error %q but got %q", tc.in, tc.errStr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unmarshal(%s): expected to see no error but got %q", tc.in, err)
+		}
+		if size != tc.expected {
+			t.Fatalf("Unmarshal(%s): expected %v but got %v", tc.in, tc.expected, size)
+		}
+		out, err := json.Marshal(size)
+		if err != nil || string(out) != tc.in {
+			t.Fatalf("Marshal(%v): expected %s but got %s, err: %q", size, tc.in, out, err)
+		}
+	}
+}
+
+func TestRingsResolve(t *testing.T) {
+	params := &RingParameters{
+		Current: map[string]uint32{RingRX: 256, RingTX: 256},
+		Max:     map[string]uint32{RingRX: 4096, RingTX: 4096},
+	}
+	rings := &Rings{RX: &RingSize{Max: true}, TX: &RingSize{Value: 1024}}
+	values, err := rings.resolve(params)
+	if err != nil {
+		t.Fatalf("resolve: expected to see no error but got %q", err)
+	}
+	expected := map[string]uint32{RingRX: 4096, RingTX: 1024}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("resolve: expected %v but got %v", expected, values)
+	}
+	rings = &Rings{RXJumbo: &RingSize{Max: true}}
+	if _, err := rings.resolve(params); err == nil {
+		t.Fatalf("resolve: expected to see an error for a ring parameter without maximum")
+	}
+}
+
+func TestExecBackendRings(t *testing.T) {
+	fake := newFakeCommand(t, []string{"-g", "ens3"}, ens3RingsOutput, "-G", nil)
+
+	backend := execBackend{}
+	params, err := backend.GetRings("ens3")
+	if err != nil {
+		t.Fatalf("GetRings(ens3): expected to see no error but got %q", err)
+	}
+	expected := &RingParameters{
+		Current: map[string]uint32{RingRX: 256, RingTX: 256},
+		Max:     map[string]uint32{RingRX: 4096, RingTX: 4096},
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("GetRings(ens3): expected %v but got %v", expected, params)
+	}
+	if err := backend.SetRings("ens3", map[string]uint32{RingTX: 512, RingRX: 1024}); err != nil {
+		t.Fatalf("SetRings(ens3): expected to see no error but got %q", err)
+	}
+	expectedParameters := [][]string{{"-G", "ens3", "rx", "1024", "tx", "512"}}
+	if !reflect.DeepEqual(fake.setParameters, expectedParameters) {
+		t.Fatalf("SetRings(ens3): expected parameters %v but got %v", expectedParameters, fake.setParameters)
+	}
+}
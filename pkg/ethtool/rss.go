@@ -0,0 +1,464 @@
+package ethtool
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	RSSHFunc    = "hfunc"
+	RSSHKey     = "hkey"
+	RSSEqual    = "equal"
+	RSSWeight   = "weight"
+	RSSTable    = "table"
+	RSSDefault  = "default"
+	RSSContexts = "contexts"
+)
+
+// RSSContext holds the receive side scaling settings of one RSS context, see ethtool -X. Settings that are not set
+// are not changed. At most one of Equal, Weight, Table and Default may be set, they all configure the indirection
+// table.
+type RSSContext struct {
+	// HFunc is the hash function, e.g. "toeplitz", "xor" or "crc32".
+	HFunc *string `json:"hfunc,omitempty"`
+	// HKey is the hash key in the notation of ethtool, e.g. "6d:5a:56:da:...". It must have the size of the hash key
+	// of the device.
+	HKey *string `json:"hkey,omitempty"`
+	// Equal spreads the traffic evenly across the first Equal RX rings.
+	Equal *uint32 `json:"equal,omitempty"`
+	// Weight spreads the traffic across the RX rings in proportion to their weights, e.g. [1, 1, 2] sends half of the
+	// traffic to ring 2.
+	Weight []uint32 `json:"weight,omitempty"`
+	// Table is the complete indirection table, i.e. the RX ring of every entry. It must have the size of the
+	// indirection table of the device.
+	Table []uint32 `json:"table,omitempty"`
+	// Default resets the indirection table to the default of the driver, see ethtool -X default. Unlike a table that
+	// is set explicitly, the driver keeps the default table in line with the channel counts. Only the default RSS
+	// context can be reset.
+	Default bool `json:"default,omitempty"`
+}
+
+// RSS holds the receive side scaling settings of an interface, see ethtool -X. The settings of the default context
+// are embedded, Contexts lists additional RSS contexts. Additional contexts are created by ApplyRSSContexts and are
+// deleted again by DeleteRSSContexts, Apply, Snapshot and Compare ignore them.
+type RSS struct {
+	RSSContext
+	Contexts []RSSContext `json:"contexts,omitempty"`
+}
+
+// IsEmpty returns true if no RSS setting is set.
+func (c *RSSContext) IsEmpty() bool {
+	return c == nil || (c.HFunc == nil && c.HKey == nil && !c.configuresTable())
+}
+
+// configuresTable returns true if c changes the indirection table.
+func (c *RSSContext) configuresTable() bool {
+	return c.Equal != nil || len(c.Weight) > 0 || len(c.Table) > 0 || c.Default
+}
+
+// Copy returns a deep copy of c.
+func (c *RSSContext) Copy() *RSSContext {
+	if c == nil {
+		return nil
+	}
+	copied := &RSSContext{Weight: slices.Clone(c.Weight), Table: slices.Clone(c.Table), Default: c.Default}
+	if c.HFunc != nil {
+		v := *c.HFunc
+		copied.HFunc = &v
+	}
+	if c.HKey != nil {
+		v := *c.HKey
+		copied.HKey = &v
+	}
+	if c.Equal != nil {
+		v := *c.Equal
+		copied.Equal = &v
+	}
+	return copied
+}
+
+// validateSchema checks the values of c that do not depend on the device and returns every problem that it finds.
+// path is the JSON path of c.
+func (c *RSSContext) validateSchema(path string) ValidationErrors {
+	var errs ValidationErrors
+	var tables []string
+	if c.Equal != nil {
+		tables = append(tables, RSSEqual)
+	}
+	if len(c.Weight) > 0 {
+		tables = append(tables, RSSWeight)
+	}
+	if len(c.Table) > 0 {
+		tables = append(tables, RSSTable)
+	}
+	if c.Default {
+		tables = append(tables, RSSDefault)
+	}
+	if len(tables) > 1 {
+		errs = append(errs, ValidationError{
+			Path:  path,
+			Value: fmt.Sprintf("%q", tables),
+			Rule:  fmt.Sprintf("must configure at most one of %q", []string{RSSEqual, RSSWeight, RSSTable, RSSDefault}),
+		})
+	}
+	if c.HFunc != nil && *c.HFunc == "" {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, RSSHFunc),
+			Value: `""`,
+			Rule:  "must not be empty",
+		})
+	}
+	if c.HKey != nil {
+		if _, err := parseHKey(*c.HKey); err != nil {
+			errs = append(errs, ValidationError{
+				Path:  joinPath(path, RSSHKey),
+				Value: fmt.Sprintf("%q", *c.HKey),
+				Rule:  fmt.Sprintf("must be a list of hexadecimal bytes separated by colons, e.g. %q", "6d:5a:56:da"),
+			})
+		}
+	}
+	if c.Equal != nil && *c.Equal == 0 {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, RSSEqual),
+			Value: "0",
+			Rule:  "must be greater than 0",
+		})
+	}
+	if len(c.Weight) > 0 && sum(c.Weight) == 0 {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, RSSWeight),
+			Value: fmt.Sprint(c.Weight),
+			Rule:  "must contain at least one weight that is greater than 0",
+		})
+	}
+	return errs
+}
+
+// validate makes sure that the device supports every configured RSS setting.
+func (c *RSSContext) validate(params *RSSParameters) error {
+	if c.HFunc != nil && !slices.Contains(params.HFuncs, *c.HFunc) {
+		return fmt.Errorf("device does not support hash function %q, supported hash functions are %q", *c.HFunc,
+			params.HFuncs)
+	}
+	if c.HKey != nil {
+		key, err := parseHKey(*c.HKey)
+		if err != nil {
+			return err
+		}
+		if size := len(params.hkey()); len(key) != size {
+			return fmt.Errorf("hash key has %d bytes, the device expects %d bytes", len(key), size)
+		}
+	}
+	if c.Equal != nil && *c.Equal > params.RXRings {
+		return fmt.Errorf("cannot spread the traffic across %d RX rings, the device has %d RX rings", *c.Equal,
+			params.RXRings)
+	}
+	if len(c.Weight) > int(params.RXRings) {
+		return fmt.Errorf("cannot weight %d RX rings, the device has %d RX rings", len(c.Weight), params.RXRings)
+	}
+	if len(c.Table) > 0 {
+		if size := len(params.Current.Table); len(c.Table) != size {
+			return fmt.Errorf("indirection table has %d entries, the device expects %d entries", len(c.Table), size)
+		}
+		for _, ring := range c.Table {
+			if ring >= params.RXRings {
+				return fmt.Errorf("indirection table refers to RX ring %d, the device has %d RX rings", ring,
+					params.RXRings)
+			}
+		}
+	}
+	return nil
+}
+
+// indirectionTable returns the indirection table that c configures for a device with an indirection table of size
+// entries, or nil if c does not change the indirection table. Weights are distributed like ethtool -X weight does.
+func (c *RSSContext) indirectionTable(size int) []uint32 {
+	switch {
+	case c.Equal != nil:
+		table := make([]uint32, size)
+		for i := range table {
+			table[i] = uint32(i) % *c.Equal
+		}
+		return table
+	case len(c.Weight) > 0:
+		table := make([]uint32, size)
+		total, partial, ring := uint64(sum(c.Weight)), uint64(0), -1
+		for i := range table {
+			for uint64(i) >= uint64(size)*partial/total {
+				ring++
+				partial += uint64(c.Weight[ring])
+			}
+			table[i] = uint32(ring)
+		}
+		return table
+	}
+	return slices.Clone(c.Table)
+}
+
+// diff returns a description of every setting that is set in c and whose value in params differs.
+func (c *RSSContext) diff(params *RSSParameters) []string {
+	var mismatches []string
+	current := params.Current
+	if c.HFunc != nil {
+		if current.HFunc == nil {
+			mismatches = append(mismatches, fmt.Sprintf("RSS setting %q is not supported", RSSHFunc))
+		} else if *c.HFunc != *current.HFunc {
+			mismatches = append(mismatches, fmt.Sprintf("RSS setting %q is %s, expected %s", RSSHFunc,
+				*current.HFunc, *c.HFunc))
+		}
+	}
+	if c.HKey != nil {
+		if current.HKey == nil {
+			mismatches = append(mismatches, fmt.Sprintf("RSS setting %q is not supported", RSSHKey))
+		} else if expected, _ := parseHKey(*c.HKey); !slices.Equal(expected, params.hkey()) {
+			mismatches = append(mismatches, fmt.Sprintf("RSS setting %q is %s, expected %s", RSSHKey,
+				*current.HKey, *c.HKey))
+		}
+	}
+	if c.configuresTable() {
+		expected := c.indirectionTable(len(current.Table))
+		if c.Default {
+			expected = defaultTable(len(current.Table), params.RXRings)
+		}
+		if !slices.Equal(expected, current.Table) {
+			mismatches = append(mismatches, fmt.Sprintf("RSS indirection table is %v, expected %v", current.Table,
+				expected))
+		}
+	}
+	return mismatches
+}
+
+func (r RSS) String() string {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// IsEmpty returns true if neither the default context nor any additional context is configured.
+func (r *RSS) IsEmpty() bool {
+	return r == nil || (r.RSSContext.IsEmpty() && len(r.Contexts) == 0)
+}
+
+// Copy returns a deep copy of r.
+func (r *RSS) Copy() *RSS {
+	if r == nil {
+		return nil
+	}
+	copied := &RSS{RSSContext: *r.RSSContext.Copy()}
+	for i := range r.Contexts {
+		copied.Contexts = append(copied.Contexts, *r.Contexts[i].Copy())
+	}
+	return copied
+}
+
+// validateSchema checks the values of r that do not depend on the device and returns every problem that it finds.
+// path is the JSON path of r.
+func (r *RSS) validateSchema(path string) ValidationErrors {
+	errs := r.RSSContext.validateSchema(path)
+	for i := range r.Contexts {
+		contextPath := fmt.Sprintf("%s[%d]", joinPath(path, RSSContexts), i)
+		if r.Contexts[i].IsEmpty() {
+			errs = append(errs, ValidationError{
+				Path:  contextPath,
+				Value: "{}",
+				Rule:  "must not be empty",
+			})
+			continue
+		}
+		if r.Contexts[i].Default {
+			errs = append(errs, ValidationError{
+				Path:  joinPath(contextPath, RSSDefault),
+				Value: "true",
+				Rule:  "must not be set, only the default RSS context can be reset",
+			})
+		}
+		errs = append(errs, r.Contexts[i].validateSchema(contextPath)...)
+	}
+	return errs
+}
+
+// RSSParameters are the RSS settings of one RSS context that a device reports. In Current, HFunc is the active hash
+// function, HKey is the hash key and Table is the complete indirection table.
+type RSSParameters struct {
+	Current RSSContext
+	// HFuncs lists the hash functions that the device supports.
+	HFuncs []string
+	// RXRings is the number of RX rings that the indirection table may refer to.
+	RXRings uint32
+}
+
+// hkey returns the hash key of the device as bytes.
+func (p *RSSParameters) hkey() []byte {
+	if p.Current.HKey == nil {
+		return nil
+	}
+	key, _ := parseHKey(*p.Current.HKey)
+	return key
+}
+
+// snapshot returns the settings of the device that c configures. The indirection table is recorded as Default if it
+// is the default table of the driver, so that restoring it does not mark the table as configured, and otherwise as
+// Equal or Weight if they describe it exactly, so that both backends can restore it.
+func (p *RSSParameters) snapshot(c *RSSContext) RSSContext {
+	var snapshot RSSContext
+	if c.HFunc != nil && p.Current.HFunc != nil {
+		v := *p.Current.HFunc
+		snapshot.HFunc = &v
+	}
+	if c.HKey != nil && p.Current.HKey != nil {
+		v := *p.Current.HKey
+		snapshot.HKey = &v
+	}
+	if c.configuresTable() {
+		if p.RXRings > 0 && slices.Equal(p.Current.Table, defaultTable(len(p.Current.Table), p.RXRings)) {
+			snapshot.Default = true
+		} else {
+			snapshot.Equal, snapshot.Weight, snapshot.Table = compactTable(p.Current.Table)
+		}
+	}
+	return snapshot
+}
+
+// defaultTable returns the indirection table of size entries that most drivers use by default, it spreads the
+// traffic evenly across all rxRings RX rings like ethtool_rxfh_indir_default does.
+func defaultTable(size int, rxRings uint32) []uint32 {
+	return (&RSSContext{Equal: &rxRings}).indirectionTable(size)
+}
+
+// compactTable returns an indirection table as the number of rings that it spreads the traffic evenly across, as
+// weights or, if neither describes it, unchanged.
+func compactTable(table []uint32) (*uint32, []uint32, []uint32) {
+	if len(table) == 0 {
+		return nil, nil, nil
+	}
+	rings := slices.Max(table) + 1
+	for equal := uint32(1); equal <= rings; equal++ {
+		c := RSSContext{Equal: &equal}
+		if slices.Equal(c.indirectionTable(len(table)), table) {
+			return &equal, nil, nil
+		}
+	}
+	weight := make([]uint32, rings)
+	for _, ring := range table {
+		weight[ring]++
+	}
+	c := RSSContext{Weight: weight}
+	if slices.Equal(c.indirectionTable(len(table)), table) {
+		return nil, weight, nil
+	}
+	return nil, nil, slices.Clone(table)
+}
+
+// parseHKey parses a hash key in the notation of ethtool, e.g. "6d:5a:56:da".
+func parseHKey(s string) ([]byte, error) {
+	var key []byte
+	for _, field := range strings.Split(s, ":") {
+		if len(field) != 2 {
+			return nil, fmt.Errorf("invalid byte %q in hash key %q", field, s)
+		}
+		b, err := hex.DecodeString(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid byte %q in hash key %q", field, s)
+		}
+		key = append(key, b[0])
+	}
+	return key, nil
+}
+
+// formatHKey returns a hash key in the notation of ethtool.
+func formatHKey(key []byte) string {
+	fields := make([]string, len(key))
+	for i, b := range key {
+		fields[i] = hex.EncodeToString([]byte{b})
+	}
+	return strings.Join(fields, ":")
+}
+
+// sum returns the sum of values.
+func sum(values []uint32) uint32 {
+	var total uint32
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// ApplyRSSContexts configures the additional RSS contexts of interface iface and returns their IDs in the order of
+// contexts. contexts[i] is applied to the context with ID ids[i] if there is one, i.e. if a previous call already
+// created it, otherwise a new context is created. Contexts of ids without a configuration are deleted. If creating a
+// context fails, the IDs of the contexts that exist at that point are returned together with the error.
+func ApplyRSSContexts(b Backend, iface string, contexts []RSSContext, ids []uint32) ([]uint32, error) {
+	if len(contexts) == 0 && len(ids) == 0 {
+		return nil, nil
+	}
+	params, err := b.GetRSS(iface, 0)
+	if err != nil {
+		return ids, err
+	}
+	result := slices.Clone(ids)
+	for i := range contexts {
+		context := contexts[i].Copy()
+		if err := context.validate(params); err != nil {
+			return result, fmt.Errorf("interface %s, RSS context %d: %w", iface, i, err)
+		}
+		if i < len(ids) {
+			if err := b.SetRSS(iface, ids[i], context); err != nil {
+				return result, err
+			}
+			continue
+		}
+		// The kernel does not allow to create a context without a change, a new context without an indirection
+		// table uses all RX rings like the default context does.
+		if !context.configuresTable() {
+			rxRings := params.RXRings
+			context.Equal = &rxRings
+		}
+		id, err := b.CreateRSSContext(iface, context)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, id)
+	}
+	if len(ids) > len(contexts) {
+		if err := DeleteRSSContexts(b, iface, ids[len(contexts):]); err != nil {
+			return result, err
+		}
+		result = result[:len(contexts)]
+	}
+	return result, nil
+}
+
+// DeleteRSSContexts deletes the RSS contexts ids of interface iface in reverse order.
+func DeleteRSSContexts(b Backend, iface string, ids []uint32) error {
+	for i := len(ids) - 1; i >= 0; i-- {
+		if err := b.DeleteRSSContext(iface, ids[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompareRSSContexts reads the additional RSS contexts ids of interface iface and returns a description of every
+// setting of contexts that differs, see ApplyRSSContexts.
+func CompareRSSContexts(b Backend, iface string, contexts []RSSContext, ids []uint32) ([]string, error) {
+	var mismatches []string
+	for i := range contexts {
+		if i >= len(ids) {
+			mismatches = append(mismatches, fmt.Sprintf("RSS context %d does not exist", i))
+			continue
+		}
+		params, err := b.GetRSS(iface, ids[i])
+		if err != nil {
+			return nil, fmt.Errorf("could not read RSS context %d of interface %s, err: %q", ids[i], iface, err)
+		}
+		for _, mismatch := range contexts[i].diff(params) {
+			mismatches = append(mismatches, fmt.Sprintf("RSS context %d: %s", i, mismatch))
+		}
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,262 @@
+package ethtool
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/utils/pointer"
+)
+
+const (
+	ens3RSSOutput = `RX flow hash indirection table for ens3 with 4 RX ring(s):
+    0:      0     1     2     3     0     1     2     3
+    8:      0     1     2     3     0     1     2     3
+RSS hash key:
+6d:5a:56:da:25:5b:0e:c2
+RSS hash function:
+    toeplitz: on
+    xor: off
+    crc32: off
+RSS input transformation:
+    symmetric-xor: off
+`
+)
+
+func TestExecBackendRSS(t *testing.T) {
+	fake := newFakeCommand(t, []string{"-x", "ens3"}, ens3RSSOutput, "-X",
+		map[string]string{"-X ens3 context new": "New RSS context is 2\n"})
+
+	backend := execBackend{}
+	params, err := backend.GetRSS("ens3", 0)
+	if err != nil {
+		t.Fatalf("GetRSS(ens3): expected to see no error but got %q", err)
+	}
+	expected := &RSSParameters{
+		Current: RSSContext{
+			HFunc: pointer.String("toeplitz"),
+			HKey:  pointer.String("6d:5a:56:da:25:5b:0e:c2"),
+			Table: []uint32{0, 1, 2, 3, 0, 1, 2, 3, 0, 1, 2, 3, 0, 1, 2, 3},
+		},
+		HFuncs:  []string{"toeplitz", "xor", "crc32"},
+		RXRings: 4,
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("GetRSS(ens3): expected %v but got %v", expected, params)
+	}
+
+	if err := backend.SetRSS("ens3", 0, &RSSContext{HFunc: pointer.String("xor"), Equal: pointer.Uint32(2)}); err != nil {
+		t.Fatalf("SetRSS(ens3): expected to see no error but got %q", err)
+	}
+	id, err := backend.CreateRSSContext("ens3", &RSSContext{Weight: []uint32{0, 0, 1, 1}})
+	if err != nil || id != 2 {
+		t.Fatalf("CreateRSSContext(ens3): expected context 2 but got %d, err: %q", id, err)
+	}
+	if err := backend.DeleteRSSContext("ens3", 2); err != nil {
+		t.Fatalf("DeleteRSSContext(ens3): expected to see no error but got %q", err)
+	}
+	if err := backend.ResetRSSTable("ens3"); err != nil {
+		t.Fatalf("ResetRSSTable(ens3): expected to see no error but got %q", err)
+	}
+	expectedParameters := [][]string{
+		{"-X", "ens3", "equal", "2", "hfunc", "xor"},
+		{"-X", "ens3", "context", "new", "weight", "0", "0", "1", "1"},
+		{"-X", "ens3", "context", "2", "delete"},
+		{"-X", "ens3", "default"},
+	}
+	if !reflect.DeepEqual(fake.setParameters, expectedParameters) {
+		t.Fatalf("SetRSS(ens3): expected parameters %v but got %v", expectedParameters, fake.setParameters)
+	}
+	if err := backend.SetRSS("ens3", 0, &RSSContext{Table: []uint32{0, 1}}); err == nil {
+		t.Fatalf("SetRSS(ens3): expected to see an error for an explicit indirection table")
+	}
+}
+
+func TestRSSIndirectionTable(t *testing.T) {
+	tcs := []struct {
+		context  RSSContext
+		expected []uint32
+	}{
+		{RSSContext{Equal: pointer.Uint32(3)}, []uint32{0, 1, 2, 0, 1, 2, 0, 1}},
+		{RSSContext{Weight: []uint32{1, 1, 2}}, []uint32{0, 0, 1, 1, 2, 2, 2, 2}},
+		{RSSContext{Weight: []uint32{0, 1}}, []uint32{1, 1, 1, 1, 1, 1, 1, 1}},
+		{RSSContext{Table: []uint32{3, 2, 1, 0, 3, 2, 1, 0}}, []uint32{3, 2, 1, 0, 3, 2, 1, 0}},
+	}
+	for _, tc := range tcs {
+		if table := tc.context.indirectionTable(8); !reflect.DeepEqual(table, tc.expected) {
+			t.Fatalf("indirectionTable(%v): expected %v but got %v", RSS{RSSContext: tc.context}, tc.expected, table)
+		}
+	}
+
+	// compactTable must describe tables with Equal or Weight where possible.
+	for _, tc := range []struct {
+		table  []uint32
+		equal  *uint32
+		weight []uint32
+	}{
+		{[]uint32{0, 1, 0, 1, 0, 1, 0, 1}, pointer.Uint32(2), nil},
+		{[]uint32{0, 0, 1, 1, 2, 2, 2, 2}, nil, []uint32{2, 2, 4}},
+		{[]uint32{3, 2, 1, 0, 3, 2, 1, 0}, nil, nil},
+	} {
+		equal, weight, table := compactTable(tc.table)
+		if !reflect.DeepEqual(equal, tc.equal) || !reflect.DeepEqual(weight, tc.weight) ||
+			(tc.equal == nil && tc.weight == nil && !reflect.DeepEqual(table, tc.table)) {
+			t.Fatalf("compactTable(%v): expected equal %v and weight %v but got %v, %v and %v", tc.table,
+				tc.equal, tc.weight, equal, weight, table)
+		}
+	}
+}
+
+func TestValidateRSS(t *testing.T) {
+	ethtoolConfigs := EthtoolConfigs{"eth0": {Self: &Settings{RSS: &RSS{
+		RSSContext: RSSContext{
+			HFunc: pointer.String(""),
+			HKey:  pointer.String("6d:5a:5"),
+			Equal: pointer.Uint32(0),
+		},
+		Contexts: []RSSContext{{Weight: []uint32{0, 0}, Table: []uint32{1}}, {}, {Default: true}},
+	}}}}
+	var paths []string
+	for _, e := range ethtoolConfigs.Validate(ConfigVersionV1) {
+		paths = append(paths, e.Path)
+	}
+	expected := []string{"ethtool.eth0.self.rss.contexts[0]", "ethtool.eth0.self.rss.contexts[0].weight",
+		"ethtool.eth0.self.rss.contexts[1]", "ethtool.eth0.self.rss.contexts[2].default", "ethtool.eth0.self.rss.equal",
+		"ethtool.eth0.self.rss.hfunc", "ethtool.eth0.self.rss.hkey"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Validate(%s): expected errors for %v but got %v", ethtoolConfigs, expected,
+			ethtoolConfigs.Validate(ConfigVersionV1))
+	}
+}
+
+func TestApplySnapshotCompareRSS(t *testing.T) {
+	backend := newFakeBackend()
+	settings := &Settings{RSS: &RSS{RSSContext: RSSContext{
+		HFunc: pointer.String("xor"),
+		HKey:  pointer.String("01:02:03:04"),
+		Table: []uint32{3, 2, 1, 0, 3, 2, 1, 0},
+	}}}
+
+	original, err := Snapshot(backend, "eth0", settings, nil)
+	if err != nil {
+		t.Fatalf("Snapshot: expected to see no error but got %q", err)
+	}
+	expectedOriginal := &Settings{RSS: &RSS{RSSContext: RSSContext{
+		HFunc: pointer.String("toeplitz"),
+		HKey:  pointer.String("6d:5a:56:da"),
+		Equal: pointer.Uint32(2),
+	}}}
+	if !reflect.DeepEqual(original, expectedOriginal) {
+		t.Fatalf("Snapshot: expected %v but got %v", expectedOriginal, original)
+	}
+	if mismatches, err := Compare(backend, "eth0", settings); err != nil || len(mismatches) != 3 {
+		t.Fatalf("Compare: expected 3 mismatches but got %v, err: %q", mismatches, err)
+	}
+	if err := Apply(backend, "eth0", settings); err != nil {
+		t.Fatalf("Apply: expected to see no error but got %q", err)
+	}
+	if mismatches, err := Compare(backend, "eth0", settings); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Compare: expected no mismatches after Apply but got %v, err: %q", mismatches, err)
+	}
+	if err := Apply(backend, "eth0", original); err != nil {
+		t.Fatalf("Apply: expected to see no error but got %q", err)
+	}
+	if mismatches, err := Compare(backend, "eth0", original); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Compare: expected no mismatches after restore but got %v, err: %q", mismatches, err)
+	}
+
+	for _, rss := range []RSSContext{
+		{HFunc: pointer.String("crc64")},
+		{HKey: pointer.String("01:02")},
+		{Equal: pointer.Uint32(5)},
+		{Table: []uint32{0, 1}},
+	} {
+		if err := Apply(backend, "eth0", &Settings{RSS: &RSS{RSSContext: rss}}); err == nil ||
+			!strings.Contains(err.Error(), "interface eth0: ") {
+			t.Fatalf("Apply(%v): expected to see an error for an unsupported setting but got %v",
+				RSS{RSSContext: rss}, err)
+		}
+	}
+}
+
+func TestApplyRSSContexts(t *testing.T) {
+	backend := newFakeBackend()
+	contexts := []RSSContext{{Weight: []uint32{0, 0, 1, 1}}, {HFunc: pointer.String("xor")}}
+	ids, err := ApplyRSSContexts(backend, "eth0", contexts, nil)
+	if err != nil || !reflect.DeepEqual(ids, []uint32{1, 2}) {
+		t.Fatalf("ApplyRSSContexts: expected contexts [1 2] but got %v, err: %q", ids, err)
+	}
+	// A new context without an indirection table uses all RX rings.
+	if table := backend.rss[2].Current.Table; !reflect.DeepEqual(table, []uint32{0, 1, 2, 3, 0, 1, 2, 3}) {
+		t.Fatalf("ApplyRSSContexts: expected context 2 to use all RX rings but got %v", table)
+	}
+	if mismatches, err := CompareRSSContexts(backend, "eth0", contexts, ids); err != nil || len(mismatches) != 0 {
+		t.Fatalf("CompareRSSContexts: expected no mismatches but got %v, err: %q", mismatches, err)
+	}
+	if mismatches, err := CompareRSSContexts(backend, "eth0", contexts, ids[:1]); err != nil ||
+		!reflect.DeepEqual(mismatches, []string{"RSS context 1 does not exist"}) {
+		t.Fatalf("CompareRSSContexts: expected a missing context but got %v, err: %q", mismatches, err)
+	}
+
+	// Applying again updates the existing contexts and deletes the ones that are no longer configured.
+	contexts = []RSSContext{{Equal: pointer.Uint32(1)}}
+	ids, err = ApplyRSSContexts(backend, "eth0", contexts, ids)
+	if err != nil || !reflect.DeepEqual(ids, []uint32{1}) {
+		t.Fatalf("ApplyRSSContexts: expected contexts [1] but got %v, err: %q", ids, err)
+	}
+	if _, ok := backend.rss[2]; ok {
+		t.Fatalf("ApplyRSSContexts: expected context 2 to be deleted")
+	}
+	if mismatches, err := CompareRSSContexts(backend, "eth0", contexts, ids); err != nil || len(mismatches) != 0 {
+		t.Fatalf("CompareRSSContexts: expected no mismatches but got %v, err: %q", mismatches, err)
+	}
+	if err := DeleteRSSContexts(backend, "eth0", ids); err != nil || len(backend.rss) != 1 {
+		t.Fatalf("DeleteRSSContexts: expected only the default context to remain but got %v, err: %q",
+			backend.rss, err)
+	}
+	if err := DeleteRSSContexts(backend, "eth0", ids); err != nil {
+		t.Fatalf("DeleteRSSContexts: expected to see no error for deleted contexts but got %q", err)
+	}
+
+	if _, err := ApplyRSSContexts(backend, "eth0", []RSSContext{{Equal: pointer.Uint32(5)}}, nil); err == nil {
+		t.Fatalf("ApplyRSSContexts: expected to see an error for an unsupported number of RX rings")
+	}
+}
+
+func TestRestoreRSSAndChannels(t *testing.T) {
+	backend := newFakeBackend()
+	backend.channels = ChannelParameters{
+		Current: map[string]uint32{ChannelRX: 0, ChannelTX: 0, ChannelCombined: 4},
+		Max:     map[string]uint32{ChannelRX: 0, ChannelTX: 0, ChannelCombined: 16},
+	}
+	backend.rss[0].Current.Table = []uint32{0, 1, 2, 3, 0, 1, 2, 3}
+	settings := &Settings{
+		Channels: &Channels{Combined: pointer.Uint32(8)},
+		RSS:      &RSS{RSSContext: RSSContext{Equal: pointer.Uint32(8)}},
+	}
+
+	original, err := Snapshot(backend, "eth0", settings, nil)
+	if err != nil {
+		t.Fatalf("Snapshot: expected to see no error but got %q", err)
+	}
+	// The original indirection table is the driver default, restoring it must not mark it as configured.
+	if !original.RSS.Default {
+		t.Fatalf("Snapshot: expected to record the driver default indirection table but got %v", original)
+	}
+	if err := Apply(backend, "eth0", settings); err != nil {
+		t.Fatalf("Apply: expected to see no error but got %q", err)
+	}
+	// The indirection table uses all 8 RX rings, so the channel counts cannot be restored first.
+	if err := Apply(backend, "eth0", original); err == nil {
+		t.Fatalf("Apply(%v): expected to see an error for removing RX rings that the indirection table uses", original)
+	}
+	if err := Restore(backend, "eth0", original); err != nil {
+		t.Fatalf("Restore(%v): expected to see no error but got %q", original, err)
+	}
+	if mismatches, err := Compare(backend, "eth0", original); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Compare: expected no mismatches after Restore but got %v, err: %q", mismatches, err)
+	}
+	if backend.rssConfigured {
+		t.Fatalf("Restore(%v): expected the indirection table to be the driver default again", original)
+	}
+}
@@ -0,0 +1,737 @@
+package ethtool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+const (
+	featuresKey = "features"
+	ringsKey    = "rings"
+	channelsKey = "channels"
+	coalesceKey = "coalesce"
+	pauseKey    = "pause"
+	linkKey     = "link"
+	rssKey      = "rss"
+	// privFlagsKey follows the naming of the ethtool --set-priv-flags option.
+	privFlagsKey = "privFlags"
+	vfKey        = "vf"
+	// flowRulesKey follows the naming of the ethtool -N rules, which ethtool calls flow rules.
+	flowRulesKey = "flowRules"
+)
+
+// Settings holds the ethtool settings of one side of an interface, e.g. of "self". In JSON, every kind of parameter
+// has its own section:
+//
+//	{"features": {"tx-checksumming": false}, "rings": {"rx": 4096, "tx": "max"}, "channels": {"combined": 4},
+//	 "coalesce": {"rx-usecs": 8, "adaptive-rx": false}, "pause": {"autoneg": false, "rx": false, "tx": false},
+//	 "link": {"speed": 25000, "duplex": "full", "autoneg": false}, "privFlags": {"disable-fw-lldp": true},
+//	 "rss": {"hfunc": "toeplitz", "equal": 4, "contexts": [{"weight": [0, 0, 1, 1]}]},
+//	 "vf": {"spoofchk": false, "trust": true},
+//	 "flowRules": [{"flow-type": "tcp4", "dst-ip": "192.0.2.0/24", "dst-port": 80, "action": 2}]}
+//
+// For compatibility with configurations that predate the sections, offloading attributes may also be listed directly
+// as booleans, e.g. {"tx-checksumming": false}. They are merged into Features.
+type Settings struct {
+	// Features holds the offloading attributes, see ethtool -K.
+	Features map[string]bool
+	// Rings holds the ring buffer sizes, see ethtool -G.
+	Rings *Rings
+	// Channels holds the channel counts, see ethtool -L.
+	Channels *Channels
+	// Coalesce holds the interrupt coalescing parameters, see ethtool -C.
+	Coalesce *Coalesce
+	// Pause holds the flow control parameters, see ethtool -A.
+	Pause *Pause
+	// Link holds the link settings, see ethtool -s.
+	Link *Link
+	// PrivFlags holds the driver-specific private flags, see ethtool --set-priv-flags.
+	PrivFlags map[string]bool
+	// RSS holds the receive side scaling settings, see ethtool -X. Apply, Snapshot and Compare only handle the
+	// default RSS context, use ApplyRSSContexts, DeleteRSSContexts and CompareRSSContexts for RSS.Contexts.
+	RSS *RSS
+	// VF holds the settings of an SR-IOV virtual function on its physical function, see ip link set <pf> vf <index>.
+	// Apply, Snapshot and Compare ignore it, use ApplyVF, SnapshotVF and CompareVF instead.
+	VF *VF
+	// FlowRules holds the receive flow classification rules, see ethtool -N. Apply, Snapshot and Compare ignore them,
+	// use ApplyFlowRules, DeleteFlowRules and CompareFlowRules instead.
+	FlowRules []FlowRule
+
+	// legacyFeatures holds the offloading attributes that were listed outside of section "features".
+	legacyFeatures []string
+}
+
+func (s *Settings) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	settings := Settings{}
+	legacy := map[string]bool{}
+	for key, value := range raw {
+		switch key {
+		case featuresKey:
+			var features map[string]bool
+			if err := json.Unmarshal(value, &features); err != nil {
+				return fmt.Errorf("invalid section %q, err: %q", key, err)
+			}
+			if settings.Features == nil {
+				settings.Features = map[string]bool{}
+			}
+			for feature, enable := range features {
+				settings.Features[feature] = enable
+			}
+		case ringsKey:
+			settings.Rings = &Rings{}
+			if err := unmarshalStrict(value, settings.Rings); err != nil {
+				return fmt.Errorf("invalid section %q, err: %q", key, err)
+			}
+		case channelsKey:
+			settings.Channels = &Channels{}
+			if err := unmarshalStrict(value, settings.Channels); err != nil {
+				return fmt.Errorf("invalid section %q, err: %q", key, err)
+			}
+		case coalesceKey:
+			settings.Coalesce = &Coalesce{}
+			if err := unmarshalStrict(value, settings.Coalesce); err != nil {
+				return fmt.Errorf("invalid section %q, err: %q", key, err)
+			}
+		case pauseKey:
+			settings.Pause = &Pause{}
+			if err := unmarshalStrict(value, settings.Pause); err != nil {
+				return fmt.Errorf("invalid section %q, err: %q", key, err)
+			}
+		case linkKey:
+			settings.Link = &Link{}
+			if err := unmarshalStrict(value, settings.Link); err != nil {
+				return fmt.Errorf("invalid section %q, err: %q", key, err)
+			}
+		case rssKey:
+			settings.RSS = &RSS{}
+			if err := unmarshalStrict(value, settings.RSS); err != nil {
+				return fmt.Errorf("invalid section %q, err: %q", key, err)
+			}
+		case privFlagsKey:
+			if err := json.Unmarshal(value, &settings.PrivFlags); err != nil {
+				return fmt.Errorf("invalid section %q, err: %q", key, err)
+			}
+		case vfKey:
+			settings.VF = &VF{}
+			if err := unmarshalStrict(value, settings.VF); err != nil {
+				return fmt.Errorf("invalid section %q, err: %q", key, err)
+			}
+		case flowRulesKey:
+			if err := unmarshalStrict(value, &settings.FlowRules); err != nil {
+				return fmt.Errorf("invalid section %q, err: %q", key, err)
+			}
+		default:
+			var enable bool
+			if err := json.Unmarshal(value, &enable); err != nil {
+				return fmt.Errorf("invalid value %s for offloading attribute %q, expected a boolean", value, key)
+			}
+			legacy[key] = enable
+		}
+	}
+	for feature, enable := range legacy {
+		if configured, ok := settings.Features[feature]; ok && configured != enable {
+			return fmt.Errorf("offloading attribute %q is set to different values inside and outside of section %q",
+				feature, featuresKey)
+		}
+		if settings.Features == nil {
+			settings.Features = map[string]bool{}
+		}
+		settings.Features[feature] = enable
+		settings.legacyFeatures = append(settings.legacyFeatures, feature)
+	}
+	sort.Strings(settings.legacyFeatures)
+	*s = settings
+	return nil
+}
+
+func (s Settings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.sections())
+}
+
+// sections returns the configured sections of s keyed by their JSON name.
+func (s Settings) sections() map[string]interface{} {
+	m := map[string]interface{}{}
+	if s.Features != nil {
+		m[featuresKey] = s.Features
+	}
+	if s.Rings != nil {
+		m[ringsKey] = s.Rings
+	}
+	if s.Channels != nil {
+		m[channelsKey] = s.Channels
+	}
+	if s.Coalesce != nil {
+		m[coalesceKey] = s.Coalesce
+	}
+	if s.Pause != nil {
+		m[pauseKey] = s.Pause
+	}
+	if s.Link != nil {
+		m[linkKey] = s.Link
+	}
+	if s.PrivFlags != nil {
+		m[privFlagsKey] = s.PrivFlags
+	}
+	if s.RSS != nil {
+		m[rssKey] = s.RSS
+	}
+	if s.VF != nil {
+		m[vfKey] = s.VF
+	}
+	if s.FlowRules != nil {
+		m[flowRulesKey] = s.FlowRules
+	}
+	return m
+}
+
+func (s Settings) String() string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// IsEmpty returns true if s does not configure anything.
+func (s *Settings) IsEmpty() bool {
+	return s == nil || (len(s.Features) == 0 && (s.Rings == nil || s.Rings.IsEmpty()) &&
+		(s.Channels == nil || s.Channels.IsEmpty()) && (s.Coalesce == nil || s.Coalesce.IsEmpty()) &&
+		s.Pause.IsEmpty() && s.Link.IsEmpty() && len(s.PrivFlags) == 0 && s.RSS.IsEmpty() && s.VF.IsEmpty() &&
+		len(s.FlowRules) == 0)
+}
+
+// Copy returns a deep copy of s.
+func (s *Settings) Copy() *Settings {
+	if s == nil {
+		return nil
+	}
+	c := &Settings{}
+	if s.Features != nil {
+		c.Features = make(map[string]bool, len(s.Features))
+		for feature, enable := range s.Features {
+			c.Features[feature] = enable
+		}
+	}
+	if s.Rings != nil {
+		c.Rings = &Rings{}
+		dst := c.Rings.fields()
+		for name, field := range s.Rings.fields() {
+			if *field != nil {
+				size := **field
+				*dst[name] = &size
+			}
+		}
+	}
+	if s.Channels != nil {
+		c.Channels = &Channels{}
+		dst := c.Channels.fields()
+		for name, v := range s.Channels.values() {
+			v := v
+			*dst[name] = &v
+		}
+	}
+	c.Coalesce = s.Coalesce.Copy()
+	c.Pause = s.Pause.Copy()
+	c.Link = s.Link.Copy()
+	if s.PrivFlags != nil {
+		c.PrivFlags = make(map[string]bool, len(s.PrivFlags))
+		for name, enable := range s.PrivFlags {
+			c.PrivFlags[name] = enable
+		}
+	}
+	c.RSS = s.RSS.Copy()
+	c.VF = s.VF.Copy()
+	if s.FlowRules != nil {
+		c.FlowRules = make([]FlowRule, len(s.FlowRules))
+		for i := range s.FlowRules {
+			c.FlowRules[i] = *s.FlowRules[i].Copy()
+		}
+	}
+	return c
+}
+
+// Apply changes the settings of interface iface. Offloading attributes are changed first, followed by private flags,
+// ring buffer sizes, channel counts, RSS settings, coalescing parameters, pause parameters and link settings. Private
+// flags come early because some of them change which other settings the driver accepts, RSS settings follow the
+// channel counts so that the indirection table refers to the final number of RX rings.
+func Apply(b Backend, iface string, s *Settings) error {
+	if s == nil {
+		return nil
+	}
+	for _, apply := range []func(Backend, string, *Settings) error{
+		applyFeatures, applyPrivFlags, applyRings, applyChannels, applyRSS, applyCoalesce, applyPause, applyLink,
+	} {
+		if err := apply(b, iface, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore changes the settings of interface iface back to s, a snapshot from before Apply, see Snapshot. It undoes
+// Apply in reverse order. The indirection table is reset before the channel counts are restored, because the kernel
+// does not remove RX rings that a configured indirection table uses, and is restored once the RX rings are back.
+func Restore(b Backend, iface string, s *Settings) error {
+	if s == nil {
+		return nil
+	}
+	for _, apply := range []func(Backend, string, *Settings) error{
+		applyLink, applyPause, applyCoalesce, resetRSSTable, applyChannels, applyRings, applyRSS, applyPrivFlags,
+		applyFeatures,
+	} {
+		if err := apply(b, iface, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyFeatures(b Backend, iface string, s *Settings) error {
+	if len(s.Features) == 0 {
+		return nil
+	}
+	return b.SetFeatures(iface, s.Features)
+}
+
+func applyPrivFlags(b Backend, iface string, s *Settings) error {
+	if len(s.PrivFlags) == 0 {
+		return nil
+	}
+	current, err := b.GetPrivFlags(iface)
+	if err != nil {
+		return err
+	}
+	if unknown := unknownPrivFlags(current, s.PrivFlags); len(unknown) > 0 {
+		return fmt.Errorf("interface %s has no private flags %q, valid flags are %q", iface, unknown,
+			privFlagNames(current))
+	}
+	return b.SetPrivFlags(iface, s.PrivFlags)
+}
+
+func applyRings(b Backend, iface string, s *Settings) error {
+	if s.Rings == nil || s.Rings.IsEmpty() {
+		return nil
+	}
+	params, err := b.GetRings(iface)
+	if err != nil {
+		return err
+	}
+	values, err := s.Rings.resolve(params)
+	if err != nil {
+		return fmt.Errorf("interface %s: %w", iface, err)
+	}
+	for name := range values {
+		if _, ok := params.Current[name]; !ok {
+			return fmt.Errorf("interface %s does not support ring parameter %q", iface, name)
+		}
+	}
+	return b.SetRings(iface, values)
+}
+
+func applyChannels(b Backend, iface string, s *Settings) error {
+	if s.Channels == nil || s.Channels.IsEmpty() {
+		return nil
+	}
+	params, err := b.GetChannels(iface)
+	if err != nil {
+		return err
+	}
+	if err := s.Channels.validate(params); err != nil {
+		return fmt.Errorf("interface %s: %w", iface, err)
+	}
+	return b.SetChannels(iface, s.Channels.values())
+}
+
+func applyRSS(b Backend, iface string, s *Settings) error {
+	if s.RSS == nil || s.RSS.RSSContext.IsEmpty() {
+		return nil
+	}
+	params, err := b.GetRSS(iface, 0)
+	if err != nil {
+		return err
+	}
+	if err := s.RSS.RSSContext.validate(params); err != nil {
+		return fmt.Errorf("interface %s: %w", iface, err)
+	}
+	rss := s.RSS.RSSContext
+	if rss.Default {
+		if err := b.ResetRSSTable(iface); err != nil {
+			return err
+		}
+		rss.Default = false
+		if rss.IsEmpty() {
+			return nil
+		}
+	}
+	return b.SetRSS(iface, 0, &rss)
+}
+
+// resetRSSTable resets the indirection table of the default RSS context before Restore changes the channel counts.
+func resetRSSTable(b Backend, iface string, s *Settings) error {
+	if s.RSS == nil || !s.RSS.configuresTable() || s.Channels == nil || s.Channels.IsEmpty() {
+		return nil
+	}
+	return b.ResetRSSTable(iface)
+}
+
+func applyCoalesce(b Backend, iface string, s *Settings) error {
+	if s.Coalesce == nil || s.Coalesce.IsEmpty() {
+		return nil
+	}
+	current, err := b.GetCoalesce(iface)
+	if err != nil {
+		return err
+	}
+	if unsupported := s.Coalesce.unsupported(current); len(unsupported) > 0 {
+		return fmt.Errorf("interface %s does not support coalescing parameters %q", iface, unsupported)
+	}
+	return b.SetCoalesce(iface, s.Coalesce)
+}
+
+func applyPause(b Backend, iface string, s *Settings) error {
+	if s.Pause.IsEmpty() {
+		return nil
+	}
+	skip, err := s.Pause.skip(b, iface)
+	if err != nil || skip {
+		return err
+	}
+	return b.SetPause(iface, s.Pause)
+}
+
+func applyLink(b Backend, iface string, s *Settings) error {
+	if s.Link.IsEmpty() {
+		return nil
+	}
+	params, err := b.GetLinkSettings(iface)
+	if err != nil {
+		return err
+	}
+	// Changing link settings renegotiates the link, and devices may not list the link mode that they use, e.g.
+	// virtual devices.
+	if s.Link.isCurrent(&params.Current) {
+		return nil
+	}
+	if err := s.Link.validate(params); err != nil {
+		return fmt.Errorf("interface %s: %w", iface, err)
+	}
+	if s.Link.MDIX != nil && params.Current.MDIX == nil {
+		return fmt.Errorf("interface %s does not support link setting %q", iface, LinkMDIX)
+	}
+	return b.SetLinkSettings(iface, s.Link)
+}
+
+// Snapshot reads the current state of interface iface for every setting that s configures and merges it into a copy
+// of original. Settings that original already holds are kept, so that repeated calls preserve the state from before the
+// first change. Applying the result restores that state.
+func Snapshot(b Backend, iface string, s *Settings, original *Settings) (*Settings, error) {
+	snapshot := original.Copy()
+	if snapshot == nil {
+		snapshot = &Settings{}
+	}
+	if s == nil {
+		return snapshot, nil
+	}
+	if len(s.Features) > 0 {
+		offloadList, err := b.ListFeatures(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read offloading attributes of interface %s, err: %q", iface, err)
+		}
+		if snapshot.Features == nil {
+			snapshot.Features = map[string]bool{}
+		}
+		for feature := range s.Features {
+			for _, name := range snapshotFeatureNames(offloadList, feature) {
+				if _, ok := snapshot.Features[name]; ok {
+					continue
+				}
+				offload, ok := lookupOffload(offloadList, name)
+				if !ok {
+					return nil, fmt.Errorf("interface %s has no offloading attribute %q", iface, name)
+				}
+				snapshot.Features[name] = offload.IsActive()
+			}
+		}
+	}
+	if len(s.PrivFlags) > 0 {
+		current, err := b.GetPrivFlags(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read private flags of interface %s, err: %q", iface, err)
+		}
+		if snapshot.PrivFlags == nil {
+			snapshot.PrivFlags = map[string]bool{}
+		}
+		for name := range s.PrivFlags {
+			if _, ok := snapshot.PrivFlags[name]; ok {
+				continue
+			}
+			enabled, ok := current[name]
+			if !ok {
+				return nil, fmt.Errorf("interface %s has no private flag %q, valid flags are %q", iface, name,
+					privFlagNames(current))
+			}
+			snapshot.PrivFlags[name] = enabled
+		}
+	}
+	if s.Rings != nil && !s.Rings.IsEmpty() {
+		params, err := b.GetRings(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read ring parameters of interface %s, err: %q", iface, err)
+		}
+		if snapshot.Rings == nil {
+			snapshot.Rings = &Rings{}
+		}
+		dst := snapshot.Rings.fields()
+		for name, field := range s.Rings.fields() {
+			if *field == nil || *dst[name] != nil {
+				continue
+			}
+			v, ok := params.Current[name]
+			if !ok {
+				return nil, fmt.Errorf("interface %s does not support ring parameter %q", iface, name)
+			}
+			*dst[name] = &RingSize{Value: v}
+		}
+	}
+	if s.Channels != nil && !s.Channels.IsEmpty() {
+		params, err := b.GetChannels(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read channel parameters of interface %s, err: %q", iface, err)
+		}
+		if snapshot.Channels == nil {
+			snapshot.Channels = &Channels{}
+		}
+		dst := snapshot.Channels.fields()
+		for name := range s.Channels.values() {
+			if *dst[name] != nil {
+				continue
+			}
+			v, ok := params.Current[name]
+			if !ok {
+				return nil, fmt.Errorf("interface %s does not support channel parameter %q", iface, name)
+			}
+			*dst[name] = &v
+		}
+	}
+	if s.RSS != nil && !s.RSS.RSSContext.IsEmpty() {
+		params, err := b.GetRSS(iface, 0)
+		if err != nil {
+			return nil, fmt.Errorf("could not read RSS settings of interface %s, err: %q", iface, err)
+		}
+		if snapshot.RSS == nil {
+			snapshot.RSS = &RSS{}
+		}
+		// The indirection table is recorded as a whole, like the link modes.
+		current, dst := params.snapshot(&s.RSS.RSSContext), &snapshot.RSS.RSSContext
+		if dst.HFunc == nil {
+			dst.HFunc = current.HFunc
+		}
+		if dst.HKey == nil {
+			dst.HKey = current.HKey
+		}
+		if !dst.configuresTable() {
+			dst.Equal, dst.Weight, dst.Table, dst.Default = current.Equal, current.Weight, current.Table, current.Default
+		}
+	}
+	if s.Coalesce != nil && !s.Coalesce.IsEmpty() {
+		current, err := b.GetCoalesce(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read coalescing parameters of interface %s, err: %q", iface, err)
+		}
+		if unsupported := s.Coalesce.unsupported(current); len(unsupported) > 0 {
+			return nil, fmt.Errorf("interface %s does not support coalescing parameters %q", iface, unsupported)
+		}
+		if snapshot.Coalesce == nil {
+			snapshot.Coalesce = &Coalesce{}
+		}
+		snapshot.Coalesce.merge(current, s.Coalesce)
+	}
+	if !s.Pause.IsEmpty() {
+		current, err := b.GetPause(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read pause parameters of interface %s, err: %q", iface, err)
+		}
+		if snapshot.Pause == nil {
+			snapshot.Pause = &Pause{}
+		}
+		dst, currentFields := snapshot.Pause.fields(), current.fields()
+		for name, field := range s.Pause.fields() {
+			if *field == nil || *dst[name] != nil {
+				continue
+			}
+			v := *currentFields[name]
+			if v == nil {
+				return nil, fmt.Errorf("interface %s does not support pause parameter %q", iface, name)
+			}
+			enabled := *v
+			*dst[name] = &enabled
+		}
+		// Restoring the pause parameters must not fail either if PFC was enabled in the meantime.
+		if snapshot.Pause.PFCAware == nil && s.Pause.PFCAware != nil {
+			pfcAware := *s.Pause.PFCAware
+			snapshot.Pause.PFCAware = &pfcAware
+		}
+	}
+	if !s.Link.IsEmpty() {
+		params, err := b.GetLinkSettings(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read link settings of interface %s, err: %q", iface, err)
+		}
+		if snapshot.Link == nil {
+			snapshot.Link = &Link{}
+		}
+		// Link modes are recorded as a whole: the advertised link modes with autonegotiation, the speed and the duplex
+		// without.
+		if current := params.Current.Copy(); s.Link.configuresLinkModes() && snapshot.Link.Autoneg == nil {
+			if current.Autoneg == nil {
+				return nil, fmt.Errorf("interface %s does not report link setting %q", iface, LinkAutoneg)
+			}
+			snapshot.Link.Autoneg = current.Autoneg
+			if *current.Autoneg {
+				snapshot.Link.Advertise = speedModes(current.Advertise)
+			} else {
+				snapshot.Link.Speed, snapshot.Link.Duplex = current.Speed, current.Duplex
+			}
+		}
+		if s.Link.MDIX != nil && snapshot.Link.MDIX == nil {
+			if params.Current.MDIX == nil {
+				return nil, fmt.Errorf("interface %s does not support link setting %q", iface, LinkMDIX)
+			}
+			mdix := *params.Current.MDIX
+			snapshot.Link.MDIX = &mdix
+		}
+	}
+	return snapshot, nil
+}
+
+// Compare reads the current state of interface iface and returns a description of every setting of s that differs
+// from it, ordered alphabetically.
+func Compare(b Backend, iface string, s *Settings) ([]string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var mismatches []string
+	if len(s.Features) > 0 {
+		offloadList, err := b.ListFeatures(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read offloading attributes of interface %s, err: %q", iface, err)
+		}
+		for feature, enable := range s.Features {
+			offload, ok := lookupOffload(offloadList, feature)
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf("offloading attribute %q does not exist", feature))
+				continue
+			}
+			if offload.IsActive() != enable {
+				fixed := ""
+				if offload.IsFixed() {
+					fixed = " [fixed]"
+				}
+				mismatches = append(mismatches, fmt.Sprintf("offloading attribute %q is %s%s, expected %s",
+					feature, status[offload.IsActive()], fixed, status[enable]))
+			}
+		}
+	}
+	if len(s.PrivFlags) > 0 {
+		current, err := b.GetPrivFlags(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read private flags of interface %s, err: %q", iface, err)
+		}
+		for name, expected := range s.PrivFlags {
+			enabled, ok := current[name]
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf("private flag %q does not exist", name))
+			} else if enabled != expected {
+				mismatches = append(mismatches, fmt.Sprintf("private flag %q is %s, expected %s", name,
+					status[enabled], status[expected]))
+			}
+		}
+	}
+	if s.Rings != nil && !s.Rings.IsEmpty() {
+		params, err := b.GetRings(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read ring parameters of interface %s, err: %q", iface, err)
+		}
+		values, err := s.Rings.resolve(params)
+		if err != nil {
+			return nil, fmt.Errorf("interface %s: %w", iface, err)
+		}
+		for name, expected := range values {
+			current, ok := params.Current[name]
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf("ring parameter %q is not supported", name))
+				continue
+			}
+			if current != expected {
+				mismatches = append(mismatches, fmt.Sprintf("ring parameter %q is %d, expected %d", name, current,
+					expected))
+			}
+		}
+	}
+	if s.Channels != nil && !s.Channels.IsEmpty() {
+		params, err := b.GetChannels(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read channel parameters of interface %s, err: %q", iface, err)
+		}
+		for name, expected := range s.Channels.values() {
+			current, ok := params.Current[name]
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf("channel parameter %q is not supported", name))
+				continue
+			}
+			if current != expected {
+				mismatches = append(mismatches, fmt.Sprintf("channel parameter %q is %d, expected %d", name,
+					current, expected))
+			}
+		}
+	}
+	if s.RSS != nil && !s.RSS.RSSContext.IsEmpty() {
+		params, err := b.GetRSS(iface, 0)
+		if err != nil {
+			return nil, fmt.Errorf("could not read RSS settings of interface %s, err: %q", iface, err)
+		}
+		mismatches = append(mismatches, s.RSS.RSSContext.diff(params)...)
+	}
+	if s.Coalesce != nil && !s.Coalesce.IsEmpty() {
+		current, err := b.GetCoalesce(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read coalescing parameters of interface %s, err: %q", iface, err)
+		}
+		mismatches = append(mismatches, s.Coalesce.diff(current)...)
+	}
+	if !s.Pause.IsEmpty() {
+		skip, err := s.Pause.skip(b, iface)
+		if err != nil {
+			return nil, err
+		}
+		if !skip {
+			current, err := b.GetPause(iface)
+			if err != nil {
+				return nil, fmt.Errorf("could not read pause parameters of interface %s, err: %q", iface, err)
+			}
+			mismatches = append(mismatches, s.Pause.diff(current)...)
+		}
+	}
+	if !s.Link.IsEmpty() {
+		params, err := b.GetLinkSettings(iface)
+		if err != nil {
+			return nil, fmt.Errorf("could not read link settings of interface %s, err: %q", iface, err)
+		}
+		mismatches = append(mismatches, s.Link.diff(&params.Current)...)
+	}
+	sort.Strings(mismatches)
+	return mismatches, nil
+}
+
+// unmarshalStrict decodes b into v and fails on unknown fields.
+func unmarshalStrict(b []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,617 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"k8s.io/utils/pointer"
+)
+
+// fakeBackend keeps the state of a single interface in memory.
+type fakeBackend struct {
+	iface     string
+	features  OffloadList
+	rings     RingParameters
+	channels  ChannelParameters
+	coalesce  Coalesce
+	pause     Pause
+	pfc       bool
+	link      LinkParameters
+	privFlags map[string]bool
+	// rss holds the RSS contexts keyed by their ID, context 0 is the default context.
+	rss map[uint32]*RSSParameters
+	// rssConfigured is true once the indirection table of the default context was set. Like the kernel, SetChannels
+	// then refuses to remove RX rings that the table uses.
+	rssConfigured bool
+	// flowRules holds the flow rules keyed by their location.
+	flowRules map[uint32]*FlowRule
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		iface: "eth0",
+		features: OffloadList{
+			"tx-checksumming": {pointer.Bool(true), pointer.Bool(false), pointer.Bool(true)},
+			"rx-checksumming": {pointer.Bool(true), pointer.Bool(false), pointer.Bool(true)},
+			"rx-all":          {pointer.Bool(false), pointer.Bool(true), pointer.Bool(false)},
+		},
+		rings: RingParameters{
+			Current: map[string]uint32{RingRX: 256, RingTX: 256},
+			Max:     map[string]uint32{RingRX: 4096, RingTX: 4096},
+		},
+		channels: ChannelParameters{
+			Current: map[string]uint32{ChannelRX: 1, ChannelTX: 1, ChannelCombined: 0},
+			Max:     map[string]uint32{ChannelRX: 16, ChannelTX: 16, ChannelCombined: 0},
+		},
+		coalesce: Coalesce{RXUsecs: pointer.Uint32(50), AdaptiveRX: pointer.Bool(true)},
+		pause:    Pause{Autoneg: pointer.Bool(false), RX: pointer.Bool(true), TX: pointer.Bool(true)},
+		link: LinkParameters{
+			Current: Link{Speed: pointer.Uint32(10000), Duplex: pointer.String(DuplexFull), Autoneg: pointer.Bool(true),
+				Advertise: []string{"1000baseT/Full", "10000baseT/Full", "Pause"}},
+			Supported: []string{"1000baseT/Full", "10000baseT/Full", "Autoneg", "Pause"},
+		},
+		privFlags: map[string]bool{"disable-fw-lldp": false, "link-down-on-close": true},
+		rss: map[uint32]*RSSParameters{0: {
+			Current: RSSContext{HFunc: pointer.String("toeplitz"), HKey: pointer.String("6d:5a:56:da"),
+				Table: []uint32{0, 1, 0, 1, 0, 1, 0, 1}},
+			HFuncs:  []string{"toeplitz", "xor", "crc32"},
+			RXRings: 4,
+		}},
+		flowRules: map[uint32]*FlowRule{},
+	}
+}
+
+func (f *fakeBackend) ListFeatures(iface string) (OffloadList, error) {
+	if iface != f.iface {
+		return nil, fmt.Errorf("no such device")
+	}
+	return f.features, nil
+}
+
+func (f *fakeBackend) FeatureNames(iface string) ([]string, error) {
+	if iface != f.iface {
+		return nil, fmt.Errorf("no such device")
+	}
+	return []string{"tx-checksum-ip-generic", "rx-checksum", "rx-all"}, nil
+}
+
+func (f *fakeBackend) SetFeatures(iface string, features map[string]bool) error {
+	if iface != f.iface {
+		return fmt.Errorf("no such device")
+	}
+	for feature, enable := range features {
+		offload, ok := f.features[feature]
+		if !ok || offload.IsFixed() {
+			return fmt.Errorf("could not change feature %q", feature)
+		}
+		f.features[feature] = Offload{pointer.Bool(enable), pointer.Bool(false), pointer.Bool(enable)}
+	}
+	return nil
+}
+
+func (f *fakeBackend) GetPrivFlags(iface string) (map[string]bool, error) {
+	if iface != f.iface {
+		return nil, fmt.Errorf("no such device")
+	}
+	privFlags := map[string]bool{}
+	for name, enabled := range f.privFlags {
+		privFlags[name] = enabled
+	}
+	return privFlags, nil
+}
+
+func (f *fakeBackend) SetPrivFlags(iface string, privFlags map[string]bool) error {
+	if iface != f.iface {
+		return fmt.Errorf("no such device")
+	}
+	for name, enable := range privFlags {
+		if _, ok := f.privFlags[name]; !ok {
+			return fmt.Errorf("no such private flag %q", name)
+		}
+		f.privFlags[name] = enable
+	}
+	return nil
+}
+
+func (f *fakeBackend) GetRings(iface string) (*RingParameters, error) {
+	if iface != f.iface {
+		return nil, fmt.Errorf("no such device")
+	}
+	return &f.rings, nil
+}
+
+func (f *fakeBackend) SetRings(iface string, rings map[string]uint32) error {
+	if iface != f.iface {
+		return fmt.Errorf("no such device")
+	}
+	for name, v := range rings {
+		if v > f.rings.Max[name] {
+			return fmt.Errorf("invalid value %d for ring parameter %q", v, name)
+		}
+		f.rings.Current[name] = v
+	}
+	return nil
+}
+
+func (f *fakeBackend) GetChannels(iface string) (*ChannelParameters, error) {
+	if iface != f.iface {
+		return nil, fmt.Errorf("no such device")
+	}
+	return &f.channels, nil
+}
+
+func (f *fakeBackend) SetChannels(iface string, channels map[string]uint32) error {
+	if iface != f.iface {
+		return fmt.Errorf("no such device")
+	}
+	current := maps.Clone(f.channels.Current)
+	for name, v := range channels {
+		current[name] = v
+	}
+	rxRings := current[ChannelRX] + current[ChannelCombined]
+	if f.rssConfigured && slices.Max(f.rss[0].Current.Table) >= rxRings {
+		return fmt.Errorf("invalid argument")
+	}
+	f.channels.Current = current
+	// RX rings follow the channel counts, and so does an indirection table that was not set.
+	f.rss[0].RXRings = rxRings
+	if !f.rssConfigured {
+		f.rss[0].Current.Table = (&RSSContext{Equal: &rxRings}).indirectionTable(len(f.rss[0].Current.Table))
+	}
+	return nil
+}
+
+func (f *fakeBackend) GetCoalesce(iface string) (*Coalesce, error) {
+	if iface != f.iface {
+		return nil, fmt.Errorf("no such device")
+	}
+	return f.coalesce.Copy(), nil
+}
+
+func (f *fakeBackend) SetCoalesce(iface string, coalesce *Coalesce) error {
+	if iface != f.iface {
+		return fmt.Errorf("no such device")
+	}
+	if coalesce.RXUsecs != nil {
+		f.coalesce.RXUsecs = coalesce.RXUsecs
+	}
+	if coalesce.AdaptiveRX != nil {
+		f.coalesce.AdaptiveRX = coalesce.AdaptiveRX
+	}
+	return nil
+}
+
+func (f *fakeBackend) GetPause(iface string) (*Pause, error) {
+	if iface != f.iface {
+		return nil, fmt.Errorf("no such device")
+	}
+	return f.pause.Copy(), nil
+}
+
+func (f *fakeBackend) SetPause(iface string, pause *Pause) error {
+	if iface != f.iface {
+		return fmt.Errorf("no such device")
+	}
+	if f.pfc {
+		return fmt.Errorf("PFC is enabled")
+	}
+	dst := f.pause.fields()
+	for name, field := range pause.fields() {
+		if *field != nil {
+			*dst[name] = *field
+		}
+	}
+	return nil
+}
+
+func (f *fakeBackend) GetLinkSettings(iface string) (*LinkParameters, error) {
+	if iface != f.iface {
+		return nil, fmt.Errorf("no such device")
+	}
+	return &LinkParameters{Current: *f.link.Current.Copy(), Supported: f.link.Supported}, nil
+}
+
+// SetLinkSettings mimics a device without a link partner that forces the speed and the duplex without
+// autonegotiation and that advertises all link modes of the speed with autonegotiation.
+func (f *fakeBackend) SetLinkSettings(iface string, link *Link) error {
+	if iface != f.iface {
+		return fmt.Errorf("no such device")
+	}
+	current := &f.link.Current
+	if link.Autoneg != nil {
+		current.Autoneg = link.Autoneg
+	}
+	if link.Speed != nil {
+		current.Speed = link.Speed
+	}
+	if link.Duplex != nil {
+		current.Duplex = link.Duplex
+	}
+	if len(link.Advertise) > 0 {
+		current.Advertise = append(slices.Clone(link.Advertise), "Pause")
+	} else if *current.Autoneg && link.Speed != nil {
+		current.Advertise = []string{"Pause"}
+		for _, mode := range speedModes(f.link.Supported) {
+			if speed, _ := parseLinkMode(mode); speed == *link.Speed {
+				current.Advertise = append(current.Advertise, mode)
+			}
+		}
+	}
+	if link.MDIX != nil {
+		return fmt.Errorf("operation not supported")
+	}
+	return nil
+}
+
+func (f *fakeBackend) GetRSS(iface string, context uint32) (*RSSParameters, error) {
+	if iface != f.iface {
+		return nil, fmt.Errorf("no such device")
+	}
+	params, ok := f.rss[context]
+	if !ok {
+		return nil, fmt.Errorf("no such RSS context %d", context)
+	}
+	return &RSSParameters{Current: *params.Current.Copy(), HFuncs: params.HFuncs, RXRings: params.RXRings}, nil
+}
+
+func (f *fakeBackend) SetRSS(iface string, context uint32, rss *RSSContext) error {
+	params, err := f.GetRSS(iface, context)
+	if err != nil {
+		return err
+	}
+	if rss.HFunc != nil {
+		params.Current.HFunc = rss.HFunc
+	}
+	if rss.HKey != nil {
+		params.Current.HKey = rss.HKey
+	}
+	if rss.configuresTable() {
+		params.Current.Table = rss.indirectionTable(len(params.Current.Table))
+		f.rssConfigured = f.rssConfigured || context == 0
+	}
+	f.rss[context] = params
+	return nil
+}
+
+func (f *fakeBackend) ResetRSSTable(iface string) error {
+	params, err := f.GetRSS(iface, 0)
+	if err != nil {
+		return err
+	}
+	params.Current.Table = (&RSSContext{Equal: &params.RXRings}).indirectionTable(len(params.Current.Table))
+	f.rss[0] = params
+	f.rssConfigured = false
+	return nil
+}
+
+func (f *fakeBackend) CreateRSSContext(iface string, rss *RSSContext) (uint32, error) {
+	if iface != f.iface {
+		return 0, fmt.Errorf("no such device")
+	}
+	id := uint32(len(f.rss))
+	for f.rss[id] != nil {
+		id++
+	}
+	f.rss[id] = f.rss[0]
+	return id, f.SetRSS(iface, id, rss)
+}
+
+func (f *fakeBackend) DeleteRSSContext(iface string, context uint32) error {
+	if iface != f.iface || context == 0 {
+		return fmt.Errorf("cannot delete RSS context %d", context)
+	}
+	delete(f.rss, context)
+	return nil
+}
+
+func (f *fakeBackend) GetFlowRule(iface string, loc uint32) (*FlowRule, error) {
+	if iface != f.iface {
+		return nil, fmt.Errorf("no such device")
+	}
+	rule, ok := f.flowRules[loc]
+	if !ok {
+		return nil, nil
+	}
+	return rule.Copy(), nil
+}
+
+func (f *fakeBackend) InsertFlowRule(iface string, rule *FlowRule) (uint32, error) {
+	if iface != f.iface {
+		return 0, fmt.Errorf("no such device")
+	}
+	if rule.Action != nil && *rule.Action >= int64(f.rss[0].RXRings) {
+		return 0, fmt.Errorf("RX ring %d does not exist", *rule.Action)
+	}
+	loc := uint32(0)
+	if rule.Loc != nil {
+		loc = *rule.Loc
+	} else {
+		for f.flowRules[loc] != nil {
+			loc++
+		}
+	}
+	if f.flowRules[loc] != nil {
+		return 0, fmt.Errorf("location %d is in use", loc)
+	}
+	f.flowRules[loc] = rule.canonical()
+	f.flowRules[loc].Loc = &loc
+	return loc, nil
+}
+
+func (f *fakeBackend) DeleteFlowRule(iface string, loc uint32) error {
+	if iface != f.iface {
+		return fmt.Errorf("no such device")
+	}
+	delete(f.flowRules, loc)
+	return nil
+}
+
+func (f *fakeBackend) PFCEnabled(iface string) (bool, error) {
+	if iface != f.iface {
+		return false, fmt.Errorf("no such device")
+	}
+	return f.pfc, nil
+}
+
+func TestSettingsJSON(t *testing.T) {
+	tcs := []struct {
+		in       string
+		expected Settings
+		errStr   string
+	}{
+		{`{"features": {"tx-checksumming": false}}`, Settings{Features: map[string]bool{"tx-checksumming": false}}, ""},
+		{`{"tx-checksumming": false}`, Settings{
+			Features:       map[string]bool{"tx-checksumming": false},
+			legacyFeatures: []string{"tx-checksumming"},
+		}, ""},
+		{`{"tx-checksumming": true, "features": {"rx-checksumming": true}, "rings": {"rx": "max", "tx": 1024}}`,
+			Settings{
+				Features:       map[string]bool{"tx-checksumming": true, "rx-checksumming": true},
+				Rings:          &Rings{RX: &RingSize{Max: true}, TX: &RingSize{Value: 1024}},
+				legacyFeatures: []string{"tx-checksumming"},
+			}, ""},
+		{`{"channels": {"rx": 4, "tx": 4}}`, Settings{
+			Channels: &Channels{RX: pointer.Uint32(4), TX: pointer.Uint32(4)},
+		}, ""},
+		{`{"coalesce": {"rx-usecs": 8, "adaptive-rx": false}}`, Settings{
+			Coalesce: &Coalesce{RXUsecs: pointer.Uint32(8), AdaptiveRX: pointer.Bool(false)},
+		}, ""},
+		{`{"vf": {"spoofchk": false, "trust": true, "max-tx-rate": 1000}}`, Settings{
+			VF: &VF{SpoofCheck: pointer.Bool(false), Trust: pointer.Bool(true), MaxTxRate: pointer.Uint32(1000)},
+		}, ""},
+		{`{"pause": {"autoneg": false, "rx": false, "tx": false, "pfc-aware": true}}`, Settings{
+			Pause: &Pause{Autoneg: pointer.Bool(false), RX: pointer.Bool(false), TX: pointer.Bool(false),
+				PFCAware: pointer.Bool(true)},
+		}, ""},
+		{`{"pause": {"rx": "off"}}`, Settings{}, "invalid section"},
+		{`{"link": {"speed": 25000, "duplex": "full", "autoneg": false, "mdix": "auto"}}`, Settings{
+			Link: &Link{Speed: pointer.Uint32(25000), Duplex: pointer.String(DuplexFull), Autoneg: pointer.Bool(false),
+				MDIX: pointer.String(MDIXAuto)},
+		}, ""},
+		{`{"link": {"advertise": ["25000baseCR/Full"]}}`, Settings{
+			Link: &Link{Advertise: []string{"25000baseCR/Full"}},
+		}, ""},
+		{`{"link": {"port": "tp"}}`, Settings{}, "unknown field"},
+		{`{"privFlags": {"disable-fw-lldp": true}}`, Settings{PrivFlags: map[string]bool{"disable-fw-lldp": true}}, ""},
+		{`{"privFlags": {"disable-fw-lldp": "on"}}`, Settings{}, "invalid section"},
+		{`{"rss": {"hfunc": "xor", "equal": 4, "contexts": [{"weight": [0, 1]}]}}`, Settings{RSS: &RSS{
+			RSSContext: RSSContext{HFunc: pointer.String("xor"), Equal: pointer.Uint32(4)},
+			Contexts:   []RSSContext{{Weight: []uint32{0, 1}}},
+		}}, ""},
+		{`{"rss": {"indir": [0, 1]}}`, Settings{}, "unknown field"},
+		{`{"vf": {"vlan": 10}}`, Settings{}, "unknown field"},
+		{`{"flowRules": [{"flow-type": "tcp4", "dst-port": 80, "action": 2}]}`, Settings{FlowRules: []FlowRule{{
+			FlowType: "tcp4", DstPort: uint16Ptr(80), Action: pointer.Int64(2),
+		}}}, ""},
+		{`{"flowRules": [{"flow-type": "tcp4", "queue": 2}]}`, Settings{}, "unknown field"},
+		{`{"tx-checksumming": "off"}`, Settings{}, "expected a boolean"},
+		{`{"rings": {"rx-jumbos": 1024}}`, Settings{}, "unknown field"},
+		{`{"channels": {"rx": "max"}}`, Settings{}, "invalid section"},
+		{`{"features": {"rx-checksumming": "on"}}`, Settings{}, "invalid section"},
+		{`{"rx-checksumming": true, "features": {"rx-checksumming": false}}`, Settings{}, "different values"},
+	}
+	for _, tc := range tcs {
+		var s Settings
+		err := json.Unmarshal([]byte(tc.in), &s)
+		if tc.errStr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.errStr) {
+				t.Fatalf("Unmarshal(%s): expected to see error %q but got %q", tc.in, tc.errStr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unmarshal(%s): expected to see no error but got %q", tc.in, err)
+		}
+		if !reflect.DeepEqual(s, tc.expected) {
+			t.Fatalf("Unmarshal(%s): expected %v but got %v", tc.in, tc.expected, s)
+		}
+		// Settings are always written in sections.
+		var roundTrip Settings
+		s.legacyFeatures = nil
+		if err := json.Unmarshal([]byte(s.String()), &roundTrip); err != nil || !reflect.DeepEqual(roundTrip, s) {
+			t.Fatalf("Marshal(%v): expected a round trip but got %v, err: %q", s, roundTrip, err)
+		}
+	}
+}
+
+func TestApplySnapshotCompare(t *testing.T) {
+	backend := newFakeBackend()
+	settings := &Settings{
+		Features:  map[string]bool{"tx-checksumming": false},
+		Rings:     &Rings{RX: &RingSize{Max: true}, TX: &RingSize{Value: 1024}},
+		Channels:  &Channels{RX: pointer.Uint32(8)},
+		Coalesce:  &Coalesce{RXUsecs: pointer.Uint32(8), AdaptiveRX: pointer.Bool(false)},
+		Pause:     &Pause{RX: pointer.Bool(false), TX: pointer.Bool(false)},
+		PrivFlags: map[string]bool{"disable-fw-lldp": true},
+	}
+
+	original, err := Snapshot(backend, "eth0", settings, nil)
+	if err != nil {
+		t.Fatalf("Snapshot: expected to see no error but got %q", err)
+	}
+	expectedOriginal := &Settings{
+		Features:  map[string]bool{"tx-checksumming": true},
+		Rings:     &Rings{RX: &RingSize{Value: 256}, TX: &RingSize{Value: 256}},
+		Channels:  &Channels{RX: pointer.Uint32(1)},
+		Coalesce:  &Coalesce{RXUsecs: pointer.Uint32(50), AdaptiveRX: pointer.Bool(true)},
+		Pause:     &Pause{RX: pointer.Bool(true), TX: pointer.Bool(true)},
+		PrivFlags: map[string]bool{"disable-fw-lldp": false},
+	}
+	if !reflect.DeepEqual(original, expectedOriginal) {
+		t.Fatalf("Snapshot: expected %v but got %v", expectedOriginal, original)
+	}
+
+	mismatches, err := Compare(backend, "eth0", settings)
+	if err != nil || len(mismatches) != 9 {
+		t.Fatalf("Compare: expected 9 mismatches but got %v, err: %q", mismatches, err)
+	}
+	if err := Apply(backend, "eth0", settings); err != nil {
+		t.Fatalf("Apply: expected to see no error but got %q", err)
+	}
+	if mismatches, err := Compare(backend, "eth0", settings); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Compare: expected no mismatches after Apply but got %v, err: %q", mismatches, err)
+	}
+	if backend.rings.Current[RingRX] != 4096 {
+		t.Fatalf("Apply: expected rx to be set to the maximum but got %d", backend.rings.Current[RingRX])
+	}
+
+	// A second snapshot must keep the values that were recorded first.
+	again, err := Snapshot(backend, "eth0", settings, original)
+	if err != nil || !reflect.DeepEqual(again, expectedOriginal) {
+		t.Fatalf("Snapshot: expected %v but got %v, err: %q", expectedOriginal, again, err)
+	}
+
+	if err := Apply(backend, "eth0", original); err != nil {
+		t.Fatalf("Apply: expected to see no error but got %q", err)
+	}
+	if mismatches, err := Compare(backend, "eth0", original); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Compare: expected no mismatches after restore but got %v, err: %q", mismatches, err)
+	}
+
+	unsupported := &Settings{Rings: &Rings{RXJumbo: &RingSize{Value: 1024}}}
+	if err := Apply(backend, "eth0", unsupported); err == nil {
+		t.Fatalf("Apply: expected to see an error for an unsupported ring parameter")
+	}
+	for _, channels := range []*Channels{{RX: pointer.Uint32(17)}, {Combined: pointer.Uint32(1)}} {
+		if err := Apply(backend, "eth0", &Settings{Channels: channels}); err == nil ||
+			!strings.Contains(err.Error(), "exceeds the maximum") {
+			t.Fatalf("Apply: expected to see an error for channel counts %v above the maximum but got %q",
+				channels.values(), err)
+		}
+	}
+	unsupported = &Settings{PrivFlags: map[string]bool{"rx_cqe_compress": true}}
+	if err := Apply(backend, "eth0", unsupported); err == nil ||
+		!strings.Contains(err.Error(), `valid flags are ["disable-fw-lldp" "link-down-on-close"]`) {
+		t.Fatalf("Apply: expected to see an error that lists the valid private flags but got %q", err)
+	}
+	unsupported = &Settings{Coalesce: &Coalesce{CQEModeRX: pointer.Bool(true)}}
+	if err := Apply(backend, "eth0", unsupported); err == nil || !strings.Contains(err.Error(), "cqe-mode-rx") {
+		t.Fatalf("Apply: expected to see an error for an unsupported coalescing parameter but got %q", err)
+	}
+
+	// Pause parameters are left alone while PFC is enabled, unless the settings are not PFC aware.
+	backend.pfc = true
+	pause := &Settings{Pause: &Pause{RX: pointer.Bool(false)}}
+	if err := Apply(backend, "eth0", pause); err == nil {
+		t.Fatalf("Apply: expected to see an error for pause parameters while PFC is enabled")
+	}
+	pause.Pause.PFCAware = pointer.Bool(true)
+	if err := Apply(backend, "eth0", pause); err != nil {
+		t.Fatalf("Apply: expected PFC aware pause parameters to be skipped but got %q", err)
+	}
+	if mismatches, err := Compare(backend, "eth0", pause); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Compare: expected PFC aware pause parameters to be skipped but got %v, err: %q", mismatches, err)
+	}
+}
+
+func TestSnapshotLegacyFeature(t *testing.T) {
+	backend := newFakeBackend()
+	backend.features["tcp-segmentation-offload"] = Offload{Active: pointer.Bool(true)}
+	backend.features["tx-tcp-segmentation"] = Offload{pointer.Bool(true), pointer.Bool(false), pointer.Bool(true)}
+	backend.features["tx-tcp-ecn-segmentation"] = Offload{pointer.Bool(false), pointer.Bool(false), pointer.Bool(false)}
+	backend.features["tx-tcp6-segmentation"] = Offload{pointer.Bool(true), pointer.Bool(false), pointer.Bool(true)}
+	backend.features["tx-tcp-mangleid-segmentation"] = Offload{pointer.Bool(false), pointer.Bool(true),
+		pointer.Bool(false)}
+	settings := &Settings{Features: map[string]bool{"tcp-segmentation-offload": false, "tx-checksumming": false}}
+
+	// Each kernel feature of a long name is recorded on its own, fixed ones are left out.
+	original, err := Snapshot(backend, "eth0", settings, nil)
+	if err != nil {
+		t.Fatalf("Snapshot: expected to see no error but got %q", err)
+	}
+	expected := map[string]bool{"tx-tcp-segmentation": true, "tx-tcp-ecn-segmentation": false,
+		"tx-tcp6-segmentation": true, "tx-checksumming": true}
+	if !reflect.DeepEqual(original.Features, expected) {
+		t.Fatalf("Snapshot: expected features %v but got %v", expected, original.Features)
+	}
+
+	// Restore turns on only the kernel features that were on before.
+	if err := backend.SetFeatures("eth0", map[string]bool{"tx-tcp-segmentation": false,
+		"tx-tcp6-segmentation": false}); err != nil {
+		t.Fatalf("SetFeatures: expected to see no error but got %q", err)
+	}
+	if err := Restore(backend, "eth0", original); err != nil {
+		t.Fatalf("Restore: expected to see no error but got %q", err)
+	}
+	for name, active := range expected {
+		if backend.features[name].IsActive() != active {
+			t.Fatalf("Restore: expected %q to be %t", name, active)
+		}
+	}
+}
+
+func TestApplySnapshotCompareLink(t *testing.T) {
+	backend := newFakeBackend()
+	forced := &Settings{Link: &Link{Speed: pointer.Uint32(1000), Duplex: pointer.String(DuplexFull),
+		Autoneg: pointer.Bool(false)}}
+	original, err := Snapshot(backend, "eth0", forced, nil)
+	if err != nil {
+		t.Fatalf("Snapshot: expected to see no error but got %q", err)
+	}
+	expectedOriginal := &Settings{Link: &Link{Autoneg: pointer.Bool(true),
+		Advertise: []string{"10000baseT/Full", "1000baseT/Full"}}}
+	if !reflect.DeepEqual(original, expectedOriginal) {
+		t.Fatalf("Snapshot: expected %v but got %v", expectedOriginal, original)
+	}
+	if mismatches, err := Compare(backend, "eth0", forced); err != nil || len(mismatches) != 2 {
+		t.Fatalf("Compare: expected 2 mismatches but got %v, err: %q", mismatches, err)
+	}
+	if err := Apply(backend, "eth0", forced); err != nil {
+		t.Fatalf("Apply: expected to see no error but got %q", err)
+	}
+	if mismatches, err := Compare(backend, "eth0", forced); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Compare: expected no mismatches after Apply but got %v, err: %q", mismatches, err)
+	}
+
+	advertise := &Settings{Link: &Link{Autoneg: pointer.Bool(true), Advertise: []string{"10000baseT/Full"}}}
+	if err := Apply(backend, "eth0", advertise); err != nil {
+		t.Fatalf("Apply: expected to see no error but got %q", err)
+	}
+	if mismatches, err := Compare(backend, "eth0", advertise); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Compare: expected no mismatches after Apply but got %v, err: %q", mismatches, err)
+	}
+
+	// Restoring the original settings advertises all link modes again.
+	if err := Apply(backend, "eth0", original); err != nil {
+		t.Fatalf("Apply: expected to see no error but got %q", err)
+	}
+	if mismatches, err := Compare(backend, "eth0", original); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Compare: expected no mismatches after restore but got %v, err: %q", mismatches, err)
+	}
+
+	for link, errStr := range map[*Link]string{
+		{Speed: pointer.Uint32(25000)}:                                    `speed 25000 and duplex any`,
+		{Speed: pointer.Uint32(1000), Duplex: pointer.String(DuplexHalf)}: `speed 1000 and duplex half`,
+		{Advertise: []string{"25000baseCR/Full"}}:                         `link mode "25000baseCR/Full"`,
+		{MDIX: pointer.String(MDIXOn)}:                                    `does not support link setting "mdix"`,
+	} {
+		if err := Apply(backend, "eth0", &Settings{Link: link}); err == nil || !strings.Contains(err.Error(), errStr) {
+			t.Fatalf("Apply(%s): expected to see error %q but got %q", link, errStr, err)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package ethtool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// configPath is the JSON path of EthtoolConfigs inside the plugin configuration.
+	configPath = "ethtool"
+	// versionPath is the JSON path of the configuration version inside the plugin configuration.
+	versionPath = "configVersion"
+)
+
+// ValidationError describes a single problem of the configuration.
+type ValidationError struct {
+	// Path is the JSON path of the offending element, e.g. "ethtool.eth0.peer".
+	Path string `json:"path"`
+	// Value is the offending value.
+	Value string `json:"value"`
+	// Rule is the rule that the value breaks.
+	Rule string `json:"rule"`
+}
+
+func (v ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s (value: %s)", v.Path, v.Rule, v.Value)
+}
+
+// ValidationErrors holds all problems of a configuration, ordered by path.
+type ValidationErrors []ValidationError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, e := range v {
+		messages = append(messages, e.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks es against the schema of the provided configuration version and returns every problem that it
+// finds, or nil.
+func (es EthtoolConfigs) Validate(version string) ValidationErrors {
+	return es.validate(configPath, version, true)
+}
+
+// ValidateOverrides checks es against the schema of the provided configuration version like Validate, for
+// configurations that override the settings of individual interfaces, see Merge. path is the JSON path of es, e.g.
+// "runtimeConfig.ethtool". Unlike Validate, it does not require "self".
+func (es EthtoolConfigs) ValidateOverrides(path, version string) ValidationErrors {
+	return es.validate(path, version, false)
+}
+
+func (es EthtoolConfigs) validate(path, version string, requireSelf bool) ValidationErrors {
+	var errs ValidationErrors
+	switch version {
+	case ConfigVersionLegacy, ConfigVersionV1:
+	default:
+		errs = append(errs, ValidationError{
+			Path:  versionPath,
+			Value: fmt.Sprintf("%q", version),
+			Rule:  fmt.Sprintf("must be omitted or one of %q", []string{ConfigVersionV1}),
+		})
+	}
+	for key, ethtoolConfig := range es {
+		if isPattern(key) {
+			if _, err := matchKey(key, ""); err != nil {
+				errs = append(errs, ValidationError{
+					Path:  joinPath(path, key),
+					Value: fmt.Sprintf("%q", key),
+					Rule:  fmt.Sprintf("invalid interface pattern: %s", err),
+				})
+			}
+		}
+		errs = append(errs, ethtoolConfig.validate(joinPath(path, key), version, requireSelf)...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs
+}
+
+func (e EthtoolConfig) validate(path, version string, requireSelf bool) ValidationErrors {
+	var errs ValidationErrors
+	if requireSelf && e.Self == nil {
+		errs = append(errs, ValidationError{
+			Path:  path,
+			Value: e.String(),
+			Rule:  fmt.Sprintf("must contain %q", SelfClassifier),
+		})
+	}
+	for _, classifier := range e.unknownClassifiers {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, classifier),
+			Value: fmt.Sprintf("%q", classifier),
+			Rule: fmt.Sprintf("unknown classifier, expected one of %q",
+				[]string{SelfClassifier, PeerClassifier, ParentClassifier}),
+		})
+	}
+	if e.Peer != nil && e.Parent != nil {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, ParentClassifier),
+			Value: e.Parent.String(),
+			Rule:  fmt.Sprintf("must not be combined with %q", PeerClassifier),
+		})
+	}
+	for _, classifier := range []string{SelfClassifier, PeerClassifier, ParentClassifier} {
+		if settings := e.Get(classifier); settings != nil {
+			errs = append(errs, settings.validate(joinPath(path, classifier), version)...)
+		}
+	}
+	if e.Self != nil && e.Self.VF != nil {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, SelfClassifier, vfKey),
+			Value: e.Self.VF.String(),
+			Rule: fmt.Sprintf("VF settings apply to the physical function, use %q or %q instead", PeerClassifier,
+				ParentClassifier),
+		})
+	}
+	return errs
+}
+
+func (s *Settings) validate(path, version string) ValidationErrors {
+	var errs ValidationErrors
+	if s.IsEmpty() {
+		errs = append(errs, ValidationError{Path: path, Value: s.String(), Rule: "must configure at least one setting"})
+	}
+	legacy := map[string]bool{}
+	for _, feature := range s.legacyFeatures {
+		legacy[feature] = true
+	}
+	if s.Features != nil && len(s.Features) == 0 {
+		errs = append(errs, emptySection(path, featuresKey))
+	}
+	// Whether a device offers a feature is only known once the device is queried, see ValidateFeatures.
+	for feature := range s.Features {
+		if legacy[feature] && version == ConfigVersionV1 {
+			errs = append(errs, ValidationError{
+				Path:  s.featurePath(path, feature),
+				Value: fmt.Sprintf("%q", feature),
+				Rule: fmt.Sprintf("offloading attributes must be part of section %q in configuration version %s",
+					featuresKey, version),
+			})
+		}
+	}
+	if s.PrivFlags != nil && len(s.PrivFlags) == 0 {
+		errs = append(errs, emptySection(path, privFlagsKey))
+	}
+	if s.Rings != nil && s.Rings.IsEmpty() {
+		errs = append(errs, emptySection(path, ringsKey))
+	}
+	if s.Channels != nil && s.Channels.IsEmpty() {
+		errs = append(errs, emptySection(path, channelsKey))
+	}
+	if s.Coalesce != nil && s.Coalesce.IsEmpty() {
+		errs = append(errs, emptySection(path, coalesceKey))
+	}
+	if s.Pause != nil && s.Pause.IsEmpty() {
+		errs = append(errs, ValidationError{
+			Path:  joinPath(path, pauseKey),
+			Value: s.Pause.String(),
+			Rule:  fmt.Sprintf("must configure at least one of %q", []string{PauseAutoneg, PauseRX, PauseTX}),
+		})
+	}
+	if s.Link != nil && s.Link.IsEmpty() {
+		errs = append(errs, emptySection(path, linkKey))
+	}
+	if s.Link != nil {
+		errs = append(errs, s.Link.validateSchema(joinPath(path, linkKey))...)
+	}
+	if s.RSS != nil && s.RSS.IsEmpty() {
+		errs = append(errs, emptySection(path, rssKey))
+	}
+	if s.RSS != nil {
+		errs = append(errs, s.RSS.validateSchema(joinPath(path, rssKey))...)
+	}
+	if s.VF != nil && s.VF.IsEmpty() {
+		errs = append(errs, emptySection(path, vfKey))
+	}
+	if s.FlowRules != nil {
+		errs = append(errs, validateFlowRules(joinPath(path, flowRulesKey), s.FlowRules)...)
+	}
+	return errs
+}
+
+func emptySection(path, section string) ValidationError {
+	return ValidationError{Path: joinPath(path, section), Value: "{}", Rule: "section must not be empty"}
+}
+
+// joinPath returns the JSON path of the provided elements.
+func joinPath(elements ...string) string {
+	return strings.Join(elements, ".")
+}
+
+// featurePath returns the JSON path of offloading attribute feature of s, which depends on whether the attribute is
+// part of section "features".
+func (s *Settings) featurePath(path, feature string) string {
+	for _, legacyFeature := range s.legacyFeatures {
+		if legacyFeature == feature {
+			return joinPath(path, feature)
+		}
+	}
+	return joinPath(path, featuresKey, feature)
+}
+
+// ValidateFeatures checks the offloading attributes that es configures for classifier of interface interfaceName
+// against the features that device iface offers. iface is interfaceName itself for "self" and the interface that it
+// is attached to for "peer" and "parent". It must be called from within the network namespace of iface. If es is the
+// result of Match, errors refer to the key that selected the interface.
+func (es EthtoolConfigs) ValidateFeatures(b Backend, interfaceName, classifier, iface string) (ValidationErrors,
+	error) {
+	key := interfaceName
+	if es[interfaceName].key != "" {
+		key = es[interfaceName].key
+	}
+	settings := es[interfaceName].Get(classifier)
+	if settings == nil || len(settings.Features) == 0 {
+		return nil, nil
+	}
+	names, err := b.FeatureNames(iface)
+	if err != nil {
+		return nil, fmt.Errorf("could not read features of interface %s, err: %q", iface, err)
+	}
+	var errs ValidationErrors
+	for _, feature := range UnsupportedFeatures(names, settings.Features) {
+		errs = append(errs, ValidationError{
+			Path:  settings.featurePath(joinPath(configPath, key, classifier), feature),
+			Value: fmt.Sprintf("%q", feature),
+			Rule:  fmt.Sprintf("interface %s does not offer this offloading attribute", iface),
+		})
+	}
+	return errs, nil
+}
@@ -0,0 +1,178 @@
+package ethtool
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/vishvananda/netlink"
+)
+
+// VF holds the settings that a physical function applies to one of its SR-IOV virtual functions, see
+// ip link set <pf> vf <index>. They are only valid for the "peer" or "parent" of a VF, which is its physical function.
+type VF struct {
+	SpoofCheck *bool   `json:"spoofchk,omitempty"`
+	Trust      *bool   `json:"trust,omitempty"`
+	MinTxRate  *uint32 `json:"min-tx-rate,omitempty"`
+	MaxTxRate  *uint32 `json:"max-tx-rate,omitempty"`
+}
+
+// IsEmpty returns true if v does not configure anything.
+func (v *VF) IsEmpty() bool {
+	return v == nil || (v.SpoofCheck == nil && v.Trust == nil && v.MinTxRate == nil && v.MaxTxRate == nil)
+}
+
+func (v VF) String() string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Copy returns a deep copy of v.
+func (v *VF) Copy() *VF {
+	if v == nil {
+		return nil
+	}
+	c := &VF{}
+	if v.SpoofCheck != nil {
+		spoofCheck := *v.SpoofCheck
+		c.SpoofCheck = &spoofCheck
+	}
+	if v.Trust != nil {
+		trust := *v.Trust
+		c.Trust = &trust
+	}
+	if v.MinTxRate != nil {
+		minTxRate := *v.MinTxRate
+		c.MinTxRate = &minTxRate
+	}
+	if v.MaxTxRate != nil {
+		maxTxRate := *v.MaxTxRate
+		c.MaxTxRate = &maxTxRate
+	}
+	return c
+}
+
+// ApplyVF changes the settings of virtual function vf of physical function pf. The rate limits are always set
+// together, so a limit that v does not configure keeps its current value.
+func ApplyVF(pf string, vf int, v *VF) error {
+	if v.IsEmpty() {
+		return nil
+	}
+	link, current, err := getVF(pf, vf)
+	if err != nil {
+		return err
+	}
+	if v.SpoofCheck != nil {
+		if err := netlink.LinkSetVfSpoofchk(link, vf, *v.SpoofCheck); err != nil {
+			return fmt.Errorf("could not set spoofchk of VF %d of interface %s, err: %q", vf, pf, err)
+		}
+	}
+	if v.Trust != nil {
+		if err := netlink.LinkSetVfTrust(link, vf, *v.Trust); err != nil {
+			return fmt.Errorf("could not set trust of VF %d of interface %s, err: %q", vf, pf, err)
+		}
+	}
+	if v.MinTxRate != nil || v.MaxTxRate != nil {
+		minTxRate, maxTxRate := current.MinTxRate, current.MaxTxRate
+		if v.MinTxRate != nil {
+			minTxRate = *v.MinTxRate
+		}
+		if v.MaxTxRate != nil {
+			maxTxRate = *v.MaxTxRate
+		}
+		if err := netlink.LinkSetVfRate(link, vf, int(minTxRate), int(maxTxRate)); err != nil {
+			return fmt.Errorf("could not set the rate of VF %d of interface %s, err: %q", vf, pf, err)
+		}
+	}
+	return nil
+}
+
+// SnapshotVF reads the current settings of virtual function vf of physical function pf for every setting that v
+// configures and merges them into a copy of original. Settings that original already holds are kept, see Snapshot.
+func SnapshotVF(pf string, vf int, v *VF, original *VF) (*VF, error) {
+	snapshot := original.Copy()
+	if v.IsEmpty() {
+		return snapshot, nil
+	}
+	if snapshot == nil {
+		snapshot = &VF{}
+	}
+	_, current, err := getVF(pf, vf)
+	if err != nil {
+		return nil, err
+	}
+	state := vfState(current)
+	if v.SpoofCheck != nil && snapshot.SpoofCheck == nil {
+		snapshot.SpoofCheck = state.SpoofCheck
+	}
+	if v.Trust != nil && snapshot.Trust == nil {
+		snapshot.Trust = state.Trust
+	}
+	if v.MinTxRate != nil && snapshot.MinTxRate == nil {
+		snapshot.MinTxRate = state.MinTxRate
+	}
+	if v.MaxTxRate != nil && snapshot.MaxTxRate == nil {
+		snapshot.MaxTxRate = state.MaxTxRate
+	}
+	return snapshot, nil
+}
+
+// CompareVF reads the current settings of virtual function vf of physical function pf and returns a description of
+// every setting of v that differs from it, ordered alphabetically.
+func CompareVF(pf string, vf int, v *VF) ([]string, error) {
+	if v.IsEmpty() {
+		return nil, nil
+	}
+	_, current, err := getVF(pf, vf)
+	if err != nil {
+		return nil, err
+	}
+	state := vfState(current)
+	var mismatches []string
+	for name, flag := range map[string][2]*bool{
+		"spoofchk": {v.SpoofCheck, state.SpoofCheck},
+		"trust":    {v.Trust, state.Trust},
+	} {
+		if flag[0] != nil && *flag[0] != *flag[1] {
+			mismatches = append(mismatches, fmt.Sprintf("VF %d parameter %q is %s, expected %s", vf, name,
+				status[*flag[1]], status[*flag[0]]))
+		}
+	}
+	for name, rate := range map[string][2]*uint32{
+		"min-tx-rate": {v.MinTxRate, state.MinTxRate},
+		"max-tx-rate": {v.MaxTxRate, state.MaxTxRate},
+	} {
+		if rate[0] != nil && *rate[0] != *rate[1] {
+			mismatches = append(mismatches, fmt.Sprintf("VF %d parameter %q is %d, expected %d", vf, name,
+				*rate[1], *rate[0]))
+		}
+	}
+	sort.Strings(mismatches)
+	return mismatches, nil
+}
+
+// getVF returns physical function pf and the current state of its virtual function vf.
+func getVF(pf string, vf int) (netlink.Link, *netlink.VfInfo, error) {
+	link, err := netlink.LinkByName(pf)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range link.Attrs().Vfs {
+		if link.Attrs().Vfs[i].ID == vf {
+			return link, &link.Attrs().Vfs[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("interface %s has no VF %d", pf, vf)
+}
+
+// vfState converts the netlink representation of a virtual function into VF.
+func vfState(info *netlink.VfInfo) *VF {
+	spoofCheck := info.Spoofchk
+	trust := info.Trust != 0
+	minTxRate := info.MinTxRate
+	maxTxRate := info.MaxTxRate
+	return &VF{SpoofCheck: &spoofCheck, Trust: &trust, MinTxRate: &minTxRate, MaxTxRate: &maxTxRate}
+}
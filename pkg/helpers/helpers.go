@@ -1,21 +1,37 @@
 package helpers
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	types100 "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 const (
 	TypeVeth      = "veth"
+	TypeMacvlan   = "macvlan"
+	TypeMacvtap   = "macvtap"
+	TypeIpvlan    = "ipvlan"
+	TypeIpvtap    = "ipvtap"
+	TypeVlan      = "vlan"
+	TypeDevice    = "device"
 	TypeNetwork   = "network"
 	NetNSLocation = "/run/netns"
 )
 
+var (
+	// SysBusPCIDevices is the sysfs directory of all PCI devices.
+	SysBusPCIDevices = "/sys/bus/pci/devices"
+)
+
 // FindExecutable checks if an executable exists inside the container. If so, it returns that path.
 // Otherwise, it also checks on /host.
 func FindExecutable(name string) ([]string, error) {
@@ -74,47 +90,140 @@ func GetInterfaceIndex(interfaceName string) (int, error) {
 	return link.Attrs().Index, nil
 }
 
-// ExtractVeth iterates over the list of provided interfaces. For each interface, it checks:
-// * That the interface is in the global namespace.
-// * That the ParentIndex (peer index) of the interface equals the peerInterfaceIndex that we are looking for.
-// * That the NetNsID equals the provided netnsID.
-// If these conditions are met, it will return the name of the matching interface.
-func ExtractVeth(interfaces []*types100.Interface, netnsID, peerInterfaceIndex int) (string, error) {
-	for _, intf := range interfaces {
-		// We are only interested in interfaces in the global namespace.
-		if intf.Sandbox != "" {
-			continue
+// IsLinkNotFound returns true if err signals that the requested interface does not exist.
+func IsLinkNotFound(err error) bool {
+	var linkNotFoundError netlink.LinkNotFoundError
+	return errors.As(err, &linkNotFoundError)
+}
+
+// HostLink is the interface in the namespace of the calling thread that an interface inside a sandbox is attached to.
+type HostLink struct {
+	Name  string
+	Index int
+	// VFIndex is the index of the sandbox interface among the virtual functions of HostLink if the sandbox interface
+	// is an SR-IOV VF and HostLink is its physical function. Otherwise, it is -1.
+	VFIndex int
+}
+
+// FindHostLink asks the kernel for the interface in the namespace of the calling thread that interface interfaceName
+// inside namespace netns is attached to, independent of what the previous plugins reported. This is the veth peer of a
+// veth interface, the lower device of a macvlan, macvtap, ipvlan, ipvtap or VLAN interface and the physical function
+// of an SR-IOV virtual function. It returns the type of interfaceName, e.g. "veth", and the attached interface.
+func FindHostLink(netns ns.NetNS, interfaceName string) (string, *HostLink, error) {
+	currentNS, err := ns.GetCurrentNS()
+	if err != nil {
+		return "", nil, err
+	}
+	defer currentNS.Close()
+
+	var linkType, busInfo string
+	var interfaceIndex, linkIndex int
+	err = netns.Do(func(_ ns.NetNS) error {
+		link, err := netlink.LinkByName(interfaceName)
+		if err != nil {
+			return err
+		}
+		linkType = link.Type()
+		interfaceIndex = link.Attrs().Index
+		switch linkType {
+		case TypeVeth, TypeMacvlan, TypeMacvtap, TypeIpvlan, TypeIpvtap, TypeVlan:
+		case TypeDevice:
+			busInfo, err = getBusInfo(interfaceName)
+			return err
+		default:
+			return fmt.Errorf("interface %s is of unsupported type %q", interfaceName, linkType)
 		}
-		// Get the netlink interface.
-		link, err := netlink.LinkByName(intf.Name)
+		// IFLA_LINK_NETNSID is the ID under which the namespace of the linked interface is known inside netns.
+		currentNSID, err := netlink.GetNetNsIdByFd(int(currentNS.Fd()))
 		if err != nil {
-			continue
+			return fmt.Errorf("could not get netns ID of the current namespace, err: %q", err)
 		}
-		// Next, make sure that the interface index of the peer matches the parent index.
-		if link.Attrs().ParentIndex != peerInterfaceIndex {
-			continue
+		if currentNSID < 0 || link.Attrs().NetNsID != currentNSID {
+			return fmt.Errorf("interface %s is not attached to an interface in the current namespace "+
+				"(link netnsID %d, current netnsID %d)", interfaceName, link.Attrs().NetNsID, currentNSID)
 		}
-		// Next, make sure that the interface's peer netns matches what we are looking for.
-		// link.Attrs().NetNsID holds the netns ID of the peer. We then compare to the netns IDs of files /run/netns.
-		if link.Attrs().NetNsID != netnsID {
-			continue
+		linkIndex = link.Attrs().ParentIndex
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if linkType == TypeDevice {
+		hostLink, err := findPhysicalFunction(busInfo)
+		if err != nil {
+			return "", nil, fmt.Errorf("interface %s: %w", interfaceName, err)
 		}
-		return intf.Name, nil
+		return linkType, hostLink, nil
 	}
-	return "", fmt.Errorf("could not find veth peer for netnsID %d, peerInterfaceIndex %d", netnsID, peerInterfaceIndex)
-}
 
-// FindNetNSID expects a path to a netns and will return the ID of the corresponding netns.
-func FindNetNSID(netnsPath string) (int, error) {
-	f, err := os.Open(netnsPath)
+	link, err := netlink.LinkByIndex(linkIndex)
 	if err != nil {
-		return -1, fmt.Errorf("could not open file %q for reading, err: %q", netnsPath, err)
+		return "", nil, fmt.Errorf("could not find the interface with index %d that interface %s is attached to, "+
+			"err: %q", linkIndex, interfaceName, err)
 	}
+	if linkType == TypeVeth {
+		// Make sure that the peer points back to the interface, so that we never return an unrelated interface.
+		netnsID, err := netlink.GetNetNsIdByFd(int(netns.Fd()))
+		if err != nil {
+			return "", nil, fmt.Errorf("could not get netns ID of namespace %s, err: %q", netns.Path(), err)
+		}
+		if link.Type() != TypeVeth || link.Attrs().ParentIndex != interfaceIndex || link.Attrs().NetNsID != netnsID {
+			return "", nil, fmt.Errorf("interface %s with index %d is not the veth peer of interface %s",
+				link.Attrs().Name, linkIndex, interfaceName)
+		}
+	}
+	return linkType, &HostLink{Name: link.Attrs().Name, Index: linkIndex, VFIndex: -1}, nil
+}
 
-	id, err := netlink.GetNetNsIdByFd(int(f.Fd()))
+// getBusInfo returns the bus address of interface interfaceName, e.g. the PCI address "0000:3b:02.0", see
+// ethtool -i.
+func getBusInfo(interfaceName string) (string, error) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return "", err
+	}
+	defer unix.Close(fd)
+	drvinfo, err := unix.IoctlGetEthtoolDrvinfo(fd, interfaceName)
 	if err != nil {
-		return -1, fmt.Errorf("issue running netlink.GetNetNsIdByFd, file: %q, err: %q", netnsPath, err)
+		return "", fmt.Errorf("could not get driver information of interface %s, err: %q", interfaceName, err)
 	}
+	return unix.ByteSliceToString(drvinfo.Bus_info[:]), nil
+}
 
-	return id, nil
+// findPhysicalFunction looks up the physical function of the SR-IOV virtual function with PCI address busInfo in sysfs.
+func findPhysicalFunction(busInfo string) (*HostLink, error) {
+	if busInfo == "" {
+		return nil, fmt.Errorf("device has no bus address and is not an SR-IOV virtual function")
+	}
+	physfn, err := os.Readlink(filepath.Join(SysBusPCIDevices, busInfo, "physfn"))
+	if err != nil {
+		return nil, fmt.Errorf("device %s is not an SR-IOV virtual function, err: %q", busInfo, err)
+	}
+	pfAddress := filepath.Base(physfn)
+	netDevices, err := os.ReadDir(filepath.Join(SysBusPCIDevices, pfAddress, "net"))
+	if err != nil || len(netDevices) == 0 {
+		return nil, fmt.Errorf("could not find the interface of physical function %s, err: %v", pfAddress, err)
+	}
+	pfName := netDevices[0].Name()
+	virtfns, err := filepath.Glob(filepath.Join(SysBusPCIDevices, pfAddress, "virtfn*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, virtfn := range virtfns {
+		target, err := os.Readlink(virtfn)
+		if err != nil || filepath.Base(target) != busInfo {
+			continue
+		}
+		vfIndex, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(virtfn), "virtfn"))
+		if err != nil {
+			continue
+		}
+		link, err := netlink.LinkByName(pfName)
+		if err != nil {
+			return nil, err
+		}
+		return &HostLink{Name: pfName, Index: link.Attrs().Index, VFIndex: vfIndex}, nil
+	}
+	return nil, fmt.Errorf("could not find virtual function %s among the virtual functions of %s", busInfo, pfName)
 }
@@ -0,0 +1,212 @@
+package kubeclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultTimeout bounds every request to the Kubernetes API, so that an unreachable API server cannot block the
+	// CNI request indefinitely.
+	DefaultTimeout = 10 * time.Second
+	// maxResponseSize bounds the size of a response that Client reads. etcd limits objects to 1.5 MiB by default, so
+	// every valid pod fits.
+	maxResponseSize = 8 << 20
+)
+
+// Client reads objects from the Kubernetes API. It only implements the subset of client-go that the plugin needs, so
+// that the plugin binary does not depend on client-go.
+type Client struct {
+	server     string
+	token      string
+	httpClient *http.Client
+}
+
+// kubeconfig is the subset of the kubeconfig file format that Client supports: TLS server verification, client
+// certificates and bearer tokens.
+type kubeconfig struct {
+	CurrentContext string `json:"current-context"`
+	Clusters       []struct {
+		Name    string `json:"name"`
+		Cluster struct {
+			Server                   string `json:"server"`
+			CertificateAuthority     string `json:"certificate-authority"`
+			CertificateAuthorityData string `json:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		} `json:"cluster"`
+	} `json:"clusters"`
+	Users []struct {
+		Name string `json:"name"`
+		User struct {
+			Token                 string `json:"token"`
+			TokenFile             string `json:"tokenFile"`
+			ClientCertificate     string `json:"client-certificate"`
+			ClientCertificateData string `json:"client-certificate-data"`
+			ClientKey             string `json:"client-key"`
+			ClientKeyData         string `json:"client-key-data"`
+		} `json:"user"`
+	} `json:"users"`
+	Contexts []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster string `json:"cluster"`
+			User    string `json:"user"`
+		} `json:"context"`
+	} `json:"contexts"`
+}
+
+// NewFromKubeconfig returns a Client for the current context of the kubeconfig file at path. Relative file references
+// inside the kubeconfig are resolved relative to its directory.
+func NewFromKubeconfig(path string) (*Client, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read kubeconfig %q, err: %q", path, err)
+	}
+	var config kubeconfig
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		return nil, fmt.Errorf("could not parse kubeconfig %q, err: %q", path, err)
+	}
+	dir := filepath.Dir(path)
+
+	var clusterName, userName string
+	for _, c := range config.Contexts {
+		if c.Name == config.CurrentContext {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+			break
+		}
+	}
+	if clusterName == "" {
+		return nil, fmt.Errorf("kubeconfig %q has no context %q", path, config.CurrentContext)
+	}
+
+	client := &Client{}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	found := false
+	for _, c := range config.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		client.server = strings.TrimSuffix(c.Cluster.Server, "/")
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		ca, err := readData(dir, c.Cluster.CertificateAuthority, c.Cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("could not read certificate authority of cluster %q, err: %q", clusterName, err)
+		}
+		if ca != nil {
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("invalid certificate authority of cluster %q", clusterName)
+			}
+		}
+	}
+	if !found || client.server == "" {
+		return nil, fmt.Errorf("kubeconfig %q has no server for cluster %q", path, clusterName)
+	}
+	for _, u := range config.Users {
+		if u.Name != userName {
+			continue
+		}
+		client.token = u.User.Token
+		if client.token == "" && u.User.TokenFile != "" {
+			token, err := readData(dir, u.User.TokenFile, "")
+			if err != nil {
+				return nil, fmt.Errorf("could not read token of user %q, err: %q", userName, err)
+			}
+			client.token = strings.TrimSpace(string(token))
+		}
+		cert, err := readData(dir, u.User.ClientCertificate, u.User.ClientCertificateData)
+		if err != nil {
+			return nil, fmt.Errorf("could not read client certificate of user %q, err: %q", userName, err)
+		}
+		key, err := readData(dir, u.User.ClientKey, u.User.ClientKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("could not read client key of user %q, err: %q", userName, err)
+		}
+		if cert != nil || key != nil {
+			keyPair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid client certificate of user %q, err: %q", userName, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{keyPair}
+		}
+	}
+	client.httpClient = &http.Client{
+		Timeout:   DefaultTimeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}
+	return client, nil
+}
+
+// readData returns the content of file, relative to dir, or the decoded base64 data. It returns nil if both are
+// empty.
+func readData(dir, file, data string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file == "" {
+		return nil, nil
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return os.ReadFile(file)
+}
+
+// PodMetadata is the metadata of a pod.
+type PodMetadata struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	UID         string            `json:"uid"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// GetPodMetadata returns the metadata of pod name in namespace.
+func (c *Client) GetPodMetadata(ctx context.Context, namespace, name string) (*PodMetadata, error) {
+	u := fmt.Sprintf("%s/api/v1/namespaces/%s/pods/%s", c.server, url.PathEscape(namespace), url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get pod %s/%s, err: %q", namespace, name, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("could not read pod %s/%s, err: %q", namespace, name, err)
+	}
+	if len(body) > maxResponseSize {
+		return nil, fmt.Errorf("could not read pod %s/%s, the response exceeds %d bytes", namespace, name,
+			maxResponseSize)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get pod %s/%s, status: %q, body: %q", namespace, name, resp.Status,
+			strings.TrimSpace(string(body)))
+	}
+	var pod struct {
+		Metadata PodMetadata `json:"metadata"`
+	}
+	if err := json.Unmarshal(body, &pod); err != nil {
+		return nil, fmt.Errorf("could not parse pod %s/%s, err: %q", namespace, name, err)
+	}
+	return &pod.Metadata, nil
+}
@@ -0,0 +1,98 @@
+package kubeclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const kubeconfigTemplate = `apiVersion: v1
+kind: Config
+current-context: node
+clusters:
+- name: other
+  cluster:
+    server: https://other.invalid
+- name: local
+  cluster:
+    server: %s
+contexts:
+- name: node
+  context:
+    cluster: local
+    user: cni
+users:
+- name: cni
+  user:
+    tokenFile: token
+`
+
+func TestGetPodMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/namespaces/db/pods/pg-0":
+			fmt.Fprint(w, `{"kind": "Pod", "metadata": {"name": "pg-0", "namespace": "db", "uid": "f00",
+				"annotations": {"cni-ethtool.io/config": "{}"}}, "spec": {}}`)
+		case "/api/v1/namespaces/db/pods/pg-2":
+			fmt.Fprintf(w, `{"kind": "Pod", "metadata": {"name": "pg-2", "annotations": {"a": "%s"}}}`,
+				strings.Repeat("x", maxResponseSize))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind": "Status", "reason": "NotFound"}`)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	kubeconfig := filepath.Join(dir, "kubeconfig")
+	if err := os.WriteFile(kubeconfig, []byte(fmt.Sprintf(kubeconfigTemplate, server.URL)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewFromKubeconfig(kubeconfig)
+	if err != nil {
+		t.Fatalf("NewFromKubeconfig: expected to see no error but got %q", err)
+	}
+	metadata, err := client.GetPodMetadata(context.Background(), "db", "pg-0")
+	if err != nil {
+		t.Fatalf("GetPodMetadata: expected to see no error but got %q", err)
+	}
+	expected := &PodMetadata{Name: "pg-0", Namespace: "db", UID: "f00",
+		Annotations: map[string]string{"cni-ethtool.io/config": "{}"}}
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Fatalf("GetPodMetadata: expected %v but got %v", expected, metadata)
+	}
+	if _, err := client.GetPodMetadata(context.Background(), "db", "pg-1"); err == nil ||
+		!strings.Contains(err.Error(), "404") {
+		t.Fatalf("GetPodMetadata: expected to see an error for a missing pod but got %q", err)
+	}
+	if _, err := client.GetPodMetadata(context.Background(), "db", "pg-2"); err == nil ||
+		!strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("GetPodMetadata: expected to see an error for an oversized response but got %q", err)
+	}
+
+	if _, err := NewFromKubeconfig(filepath.Join(dir, "missing")); err == nil {
+		t.Fatalf("NewFromKubeconfig: expected to see an error for a missing kubeconfig")
+	}
+	invalid := strings.Replace(fmt.Sprintf(kubeconfigTemplate, server.URL), "current-context: node",
+		"current-context: pod", 1)
+	if err := os.WriteFile(kubeconfig, []byte(invalid), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromKubeconfig(kubeconfig); err == nil || !strings.Contains(err.Error(), "no context") {
+		t.Fatalf("NewFromKubeconfig: expected to see an error for a missing context but got %q", err)
+	}
+}
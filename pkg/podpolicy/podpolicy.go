@@ -0,0 +1,194 @@
+package podpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/andreaskaris/cni-ethtool/pkg/ethtool"
+	"github.com/containernetworking/cni/pkg/types"
+)
+
+const (
+	// DefaultDir is the directory of the policy files if the plugin configuration does not provide a different
+	// location. Every file with suffix ".json" is a policy file.
+	DefaultDir = "/etc/cni/ethtool.d"
+	fileSuffix = ".json"
+)
+
+// K8sArgs holds the CNI_ARGS that Kubernetes runtimes pass for every pod.
+type K8sArgs struct {
+	types.CommonArgs
+	K8S_POD_NAMESPACE types.UnmarshallableString
+	K8S_POD_NAME      types.UnmarshallableString
+	K8S_POD_UID       types.UnmarshallableString
+}
+
+// Pod identifies the pod that a CNI request is for.
+type Pod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	UID       string `json:"uid,omitempty"`
+}
+
+// IsEmpty returns true if the CNI request did not identify a pod.
+func (p Pod) IsEmpty() bool {
+	return p.Namespace == "" && p.Name == "" && p.UID == ""
+}
+
+// ParseArgs extracts the pod from CNI_ARGS, e.g. "K8S_POD_NAMESPACE=default;K8S_POD_NAME=web-0". All other
+// arguments are ignored.
+func ParseArgs(args string) (Pod, error) {
+	k8sArgs := K8sArgs{}
+	k8sArgs.IgnoreUnknown = true
+	if err := types.LoadArgs(args, &k8sArgs); err != nil {
+		return Pod{}, fmt.Errorf("could not parse CNI_ARGS %q, err: %q", args, err)
+	}
+	return Pod{
+		Namespace: string(k8sArgs.K8S_POD_NAMESPACE),
+		Name:      string(k8sArgs.K8S_POD_NAME),
+		UID:       string(k8sArgs.K8S_POD_UID),
+	}, nil
+}
+
+// Selector selects pods. Namespace and Name are globs, see path.Match, and UID must match exactly. Empty fields match
+// every pod.
+type Selector struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	UID       string `json:"uid,omitempty"`
+}
+
+// Matches returns true if s selects pod.
+func (s Selector) Matches(pod Pod) bool {
+	if s.UID != "" && s.UID != pod.UID {
+		return false
+	}
+	for _, field := range [][2]string{{s.Namespace, pod.Namespace}, {s.Name, pod.Name}} {
+		pattern, value := field[0], field[1]
+		if pattern == "" {
+			continue
+		}
+		if ok, err := path.Match(pattern, value); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Policy applies Ethtool to every pod that Selector selects. Ethtool overrides the settings of the plugin
+// configuration per interface, see ethtool.EthtoolConfigs.Merge.
+type Policy struct {
+	Selector Selector               `json:"selector"`
+	Ethtool  ethtool.EthtoolConfigs `json:"ethtool"`
+	// Source is the file and position of the policy, e.g. "/etc/cni/ethtool.d/10-db.json:policies[0]".
+	Source string `json:"-"`
+}
+
+// File is the content of a policy file:
+//
+//	{"configVersion": "v1", "policies": [{"selector": {"namespace": "db", "name": "postgres-*"},
+//	 "ethtool": {"eth0": {"self": {"rings": {"rx": "max"}}}}}]}
+type File struct {
+	// ConfigVersion selects the schema of the Ethtool sections of all policies, see ethtool.ConfigVersionV1.
+	ConfigVersion string   `json:"configVersion"`
+	Policies      []Policy `json:"policies"`
+}
+
+// Load reads and validates all policy files inside dir in lexical order. It returns the policies in the order of the
+// files and of the policies inside each file. A directory that does not exist holds no policies.
+func Load(dir string) ([]Policy, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read policy directory %q, err: %q", dir, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == fileSuffix {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	var policies []Policy
+	for _, name := range names {
+		p := filepath.Join(dir, name)
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("could not read policy file %q, err: %q", p, err)
+		}
+		var file File
+		if err := json.Unmarshal(b, &file); err != nil {
+			return nil, fmt.Errorf("could not parse policy file %q, err: %q", p, err)
+		}
+		for i, policy := range file.Policies {
+			policy.Source = fmt.Sprintf("%s:policies[%d]", p, i)
+			if err := policy.validate(file.ConfigVersion); err != nil {
+				return nil, err
+			}
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+// validate checks the selector and the settings of p.
+func (p Policy) validate(version string) error {
+	for _, pattern := range []string{p.Selector.Namespace, p.Selector.Name} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("policy %s has an invalid selector %q, err: %q", p.Source, pattern, err)
+		}
+	}
+	if len(p.Ethtool) == 0 {
+		return fmt.Errorf("policy %s does not configure any interface", p.Source)
+	}
+	if errs := p.Ethtool.ValidateOverrides("ethtool", version); len(errs) > 0 {
+		return fmt.Errorf("policy %s is not valid: %w", p.Source, errs)
+	}
+	return nil
+}
+
+// Lookup returns the policies inside dir that select pod, in the order of Load.
+func Lookup(dir string, pod Pod) ([]Policy, error) {
+	policies, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	var matched []Policy
+	for _, policy := range policies {
+		if policy.Selector.Matches(pod) {
+			matched = append(matched, policy)
+		}
+	}
+	return matched, nil
+}
+
+// ConfigAnnotation is the pod annotation that holds the per-pod settings as EthtoolConfigs in JSON, e.g.
+//
+//	cni-ethtool.io/config: '{"eth0": {"self": {"features": {"rx-gro": false}}}}'
+//
+// The settings override the settings of the plugin configuration per interface, like the settings of a Policy.
+const ConfigAnnotation = "cni-ethtool.io/config"
+
+// FromAnnotations parses and validates annotation ConfigAnnotation of a pod against the provided configuration
+// version. It returns nil if the pod does not have the annotation.
+func FromAnnotations(annotations map[string]string, version string) (ethtool.EthtoolConfigs, error) {
+	value, ok := annotations[ConfigAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var ethtoolConfigs ethtool.EthtoolConfigs
+	if err := json.Unmarshal([]byte(value), &ethtoolConfigs); err != nil {
+		return nil, fmt.Errorf("could not parse annotation %q, err: %q", ConfigAnnotation, err)
+	}
+	path := fmt.Sprintf("metadata.annotations[%s]", ConfigAnnotation)
+	if errs := ethtoolConfigs.ValidateOverrides(path, version); len(errs) > 0 {
+		return nil, fmt.Errorf("annotation %q is not valid: %w", ConfigAnnotation, errs)
+	}
+	return ethtoolConfigs, nil
+}
@@ -0,0 +1,107 @@
+package podpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	pod, err := ParseArgs("IgnoreUnknown=1;K8S_POD_NAMESPACE=db;K8S_POD_NAME=pg-0;" +
+		"K8S_POD_INFRA_CONTAINER_ID=1234;K8S_POD_UID=f00")
+	if err != nil {
+		t.Fatalf("ParseArgs: expected to see no error but got %q", err)
+	}
+	if expected := (Pod{Namespace: "db", Name: "pg-0", UID: "f00"}); pod != expected {
+		t.Fatalf("ParseArgs: expected %v but got %v", expected, pod)
+	}
+	if pod, err := ParseArgs(""); err != nil || !pod.IsEmpty() {
+		t.Fatalf("ParseArgs: expected an empty pod for empty CNI_ARGS but got %v, err: %q", pod, err)
+	}
+	if _, err := ParseArgs("K8S_POD_NAME"); err == nil {
+		t.Fatalf("ParseArgs: expected to see an error for an invalid pair")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"20-db.json": `{"configVersion": "v1", "policies": [
+			{"selector": {"namespace": "db", "name": "pg-*"}, "ethtool": {"eth0": {"self": {"rings": {"rx": "max"}}}}},
+			{"selector": {"uid": "f00"}, "ethtool": {"net*": {"self": {"features": {"rx-gro": false}}}}}
+		]}`,
+		"10-all.json": `{"policies": [{"selector": {}, "ethtool": {"*": {"peer": {"tx-checksumming": false}}}}]}`,
+		"README.md":   "not a policy",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tcs := []struct {
+		pod      Pod
+		expected []string
+	}{
+		{Pod{Namespace: "db", Name: "pg-0", UID: "f00"},
+			[]string{"10-all.json:policies[0]", "20-db.json:policies[0]", "20-db.json:policies[1]"}},
+		{Pod{Namespace: "db", Name: "redis-0"}, []string{"10-all.json:policies[0]"}},
+		{Pod{Namespace: "web", Name: "pg-0", UID: "f00"}, []string{"10-all.json:policies[0]", "20-db.json:policies[1]"}},
+	}
+	for _, tc := range tcs {
+		policies, err := Lookup(dir, tc.pod)
+		if err != nil {
+			t.Fatalf("Lookup(%v): expected to see no error but got %q", tc.pod, err)
+		}
+		var sources []string
+		for _, policy := range policies {
+			sources = append(sources, strings.TrimPrefix(policy.Source, dir+"/"))
+		}
+		if strings.Join(sources, ",") != strings.Join(tc.expected, ",") {
+			t.Fatalf("Lookup(%v): expected %v but got %v", tc.pod, tc.expected, sources)
+		}
+	}
+
+	if policies, err := Lookup(filepath.Join(dir, "missing"), tcs[0].pod); err != nil || policies != nil {
+		t.Fatalf("Lookup: expected no policies for a missing directory but got %v, err: %q", policies, err)
+	}
+
+	invalid := map[string]string{
+		`{"configVersion": "v1", "policies": [{"ethtool": {"eth0": {"self": {"rx-gro": false}}}}]}`:     "section",
+		`{"policies": [{"selector": {"name": "["}, "ethtool": {"eth0": {"self": {"rx-gro": false}}}}]}`: "selector",
+		`{"policies": [{"selector": {"name": "pg-0"}}]}`:                                                "does not configure any interface",
+		`{"policies": {}}`: "could not parse",
+	}
+	for content, errStr := range invalid {
+		if err := os.WriteFile(filepath.Join(dir, "30-invalid.json"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Lookup(dir, tcs[0].pod); err == nil || !strings.Contains(err.Error(), errStr) {
+			t.Fatalf("Lookup: expected to see error %q for policy file %s but got %q", errStr, content, err)
+		}
+	}
+}
+
+func TestFromAnnotations(t *testing.T) {
+	annotations := map[string]string{ConfigAnnotation: `{"eth0": {"peer": {"features": {"rx-gro": false}}}}`}
+	ethtoolConfigs, err := FromAnnotations(annotations, "v1")
+	if err != nil || ethtoolConfigs["eth0"].Peer == nil {
+		t.Fatalf("FromAnnotations(%v): expected settings for eth0 but got %v, err: %q", annotations,
+			ethtoolConfigs, err)
+	}
+	if ethtoolConfigs, err := FromAnnotations(map[string]string{"other": "{}"}, "v1"); err != nil ||
+		ethtoolConfigs != nil {
+		t.Fatalf("FromAnnotations: expected no settings without the annotation but got %v, err: %q",
+			ethtoolConfigs, err)
+	}
+	for value, errStr := range map[string]string{
+		`{"eth0": {"peer": {"rx-gro": false}}}`: "metadata.annotations[cni-ethtool.io/config].eth0.peer.rx-gro",
+		`[]`:                                    "could not parse",
+	} {
+		if _, err := FromAnnotations(map[string]string{ConfigAnnotation: value}, "v1"); err == nil ||
+			!strings.Contains(err.Error(), errStr) {
+			t.Fatalf("FromAnnotations(%s): expected to see error %q but got %q", value, errStr, err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andreaskaris/cni-ethtool/pkg/ethtool"
+)
+
+const (
+	// DefaultDir is the directory where the original interface settings are stored if the plugin configuration
+	// does not provide a different location.
+	DefaultDir = "/var/lib/cni/cni-ethtool"
+	fileSuffix = ".json"
+)
+
+// Record holds the settings of an interface and of its peer as they were before cmdAdd modified them. It is
+// keyed by container ID and interface name.
+type Record struct {
+	ContainerID   string `json:"containerID"`
+	InterfaceName string `json:"interfaceName"`
+	// Self holds the original settings of the interface inside the sandbox.
+	Self *ethtool.Settings `json:"self,omitempty"`
+	// Peer holds the original settings of the interface in the global namespace that the interface is attached to,
+	// configured as "peer" or "parent": the veth peer, the lower device or the physical function of an SR-IOV VF.
+	// PeerInterfaceName and PeerInterfaceIndex identify the peer, so that we never touch an interface that was
+	// recreated with the same name in the meantime.
+	Peer               *ethtool.Settings `json:"peer,omitempty"`
+	PeerInterfaceName  string            `json:"peerInterfaceName,omitempty"`
+	PeerInterfaceIndex int               `json:"peerInterfaceIndex,omitempty"`
+	// VFIndex is the index of the SR-IOV virtual function whose settings Peer.VF holds. It is only meaningful if
+	// Peer.VF is set.
+	VFIndex int `json:"vfIndex,omitempty"`
+	// SelfRSSContexts and PeerRSSContexts hold the IDs of the RSS contexts that cmdAdd created on the interface and on
+	// its peer, in the order of their configuration. Unlike the settings, they are recorded after the change.
+	SelfRSSContexts []uint32 `json:"selfRSSContexts,omitempty"`
+	PeerRSSContexts []uint32 `json:"peerRSSContexts,omitempty"`
+	// SelfFlowRules and PeerFlowRules hold the flow rules that cmdAdd installed, with their locations, like the RSS
+	// contexts. cmdDel only deletes a rule if the rule at its location still matches it.
+	SelfFlowRules []ethtool.FlowRule `json:"selfFlowRules,omitempty"`
+	PeerFlowRules []ethtool.FlowRule `json:"peerFlowRules,omitempty"`
+}
+
+// NewRecord returns an empty record for the provided container ID and interface name.
+func NewRecord(containerID, interfaceName string) *Record {
+	return &Record{
+		ContainerID:   containerID,
+		InterfaceName: interfaceName,
+		Self:          &ethtool.Settings{},
+		Peer:          &ethtool.Settings{},
+	}
+}
+
+// Store persists records on disk, one file per interface inside a directory per container:
+// <dir>/<containerID>/<interfaceName>.json
+type Store struct {
+	dir string
+}
+
+// New returns a Store that is rooted at dir. If dir is empty, DefaultDir is used.
+func New(dir string) *Store {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return &Store{dir: dir}
+}
+
+// Load returns the record for the provided container ID and interface name. It returns nil and no error if no such
+// record exists.
+func (s *Store) Load(containerID, interfaceName string) (*Record, error) {
+	p, err := s.path(containerID, interfaceName)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read state file %q, err: %q", p, err)
+	}
+	record := &Record{}
+	if err := json.Unmarshal(b, record); err != nil {
+		return nil, fmt.Errorf("could not parse state file %q, err: %q", p, err)
+	}
+	return record, nil
+}
+
+// List returns all records for the provided container ID.
+func (s *Store) List(containerID string) ([]*Record, error) {
+	if err := validateKey(containerID); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(s.dir, containerID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not list state for container %q, err: %q", containerID, err)
+	}
+	var records []*Record
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileSuffix) {
+			continue
+		}
+		record, err := s.Load(containerID, strings.TrimSuffix(entry.Name(), fileSuffix))
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// Save writes the record to disk. The file is written to a temporary location first and then renamed, so that a
+// crash never leaves a truncated record behind.
+func (s *Store) Save(record *Record) error {
+	p, err := s.path(record.ContainerID, record.InterfaceName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return fmt.Errorf("could not create state directory %q, err: %q", filepath.Dir(p), err)
+	}
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+record.InterfaceName)
+	if err != nil {
+		return fmt.Errorf("could not create state file in %q, err: %q", filepath.Dir(p), err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write state file %q, err: %q", tmp.Name(), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not sync state file %q, err: %q", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("could not move state file to %q, err: %q", p, err)
+	}
+	return nil
+}
+
+// Delete removes the record for the provided container ID and interface name. The container directory is removed
+// together with its last record. Deleting a record that does not exist is not an error.
+func (s *Store) Delete(containerID, interfaceName string) error {
+	p, err := s.path(containerID, interfaceName)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not delete state file %q, err: %q", p, err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(p))
+	if err == nil && len(entries) == 0 {
+		_ = os.Remove(filepath.Dir(p))
+	}
+	return nil
+}
+
+func (s *Store) path(containerID, interfaceName string) (string, error) {
+	if err := validateKey(containerID); err != nil {
+		return "", err
+	}
+	if err := validateKey(interfaceName); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, containerID, interfaceName+fileSuffix), nil
+}
+
+// validateKey makes sure that a container ID or interface name can safely be used as a path element.
+func validateKey(key string) error {
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, "/\x00") {
+		return fmt.Errorf("invalid state key %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/andreaskaris/cni-ethtool/pkg/ethtool"
+	"k8s.io/utils/pointer"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store := New(dir)
+
+	record, err := store.Load("container1", "eth0")
+	if err != nil || record != nil {
+		t.Fatalf("Load: expected no record and no error for an empty store, got %v, err: %q", record, err)
+	}
+
+	eth0 := NewRecord("container1", "eth0")
+	eth0.Self.Features = map[string]bool{"tx-checksumming": true}
+	eth0.Peer.Features = map[string]bool{"rx-checksumming": false}
+	eth0.PeerInterfaceName = "veth1234"
+	eth0.PeerInterfaceIndex = 10
+	eth0.SelfRSSContexts = []uint32{1, 2}
+	eth0.PeerFlowRules = []ethtool.FlowRule{{FlowType: "tcp4", Action: pointer.Int64(1), Loc: pointer.Uint32(1023)}}
+	net1 := NewRecord("container1", "net1")
+	net1.Self.Features = map[string]bool{"generic-receive-offload": false}
+	other := NewRecord("container2", "eth0")
+	for _, r := range []*Record{eth0, net1, other} {
+		if err := store.Save(r); err != nil {
+			t.Fatalf("Save(%v): expected no error but got %q", r, err)
+		}
+	}
+
+	record, err = store.Load("container1", "eth0")
+	if err != nil {
+		t.Fatalf("Load: expected no error but got %q", err)
+	}
+	if !reflect.DeepEqual(record, eth0) {
+		t.Fatalf("Load: expected %v but got %v", eth0, record)
+	}
+
+	records, err := store.List("container1")
+	if err != nil {
+		t.Fatalf("List: expected no error but got %q", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("List: expected 2 records but got %v", records)
+	}
+
+	for _, interfaceName := range []string{"eth0", "net1", "net1"} {
+		if err := store.Delete("container1", interfaceName); err != nil {
+			t.Fatalf("Delete(%s): expected no error but got %q", interfaceName, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "container1")); !os.IsNotExist(err) {
+		t.Fatalf("Delete: expected the container directory to be removed, got err: %q", err)
+	}
+	if records, err := store.List("container2"); err != nil || len(records) != 1 {
+		t.Fatalf("List: expected the records of other containers to be kept, got %v, err: %q", records, err)
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	store := New(t.TempDir())
+	for _, key := range []string{"", ".", "..", "a/b"} {
+		if _, err := store.Load(key, "eth0"); err == nil {
+			t.Fatalf("Load(%q): expected an error", key)
+		}
+		if _, err := store.Load("container1", key); err == nil {
+			t.Fatalf("Load(container1, %q): expected an error", key)
+		}
+	}
+}